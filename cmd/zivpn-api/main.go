@@ -0,0 +1,1832 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"zivpn/internal/config"
+	"zivpn/internal/storage"
+)
+
+const (
+	ConfigFile = config.ServerConfig
+	UserDB     = config.UsersFile
+	AuditLog   = config.AuditLog
+)
+
+var AuthToken = config.DefaultApiKey
+
+type Config struct {
+	Listen string `json:"listen"`
+	Cert   string `json:"cert"`
+	Key    string `json:"key"`
+	Obfs   string `json:"obfs"`
+	Auth   struct {
+		Mode   string   `json:"mode"`
+		Config []string `json:"config"`
+	} `json:"auth"`
+}
+
+type UserRequest struct {
+	Password    string `json:"password"`
+	NewPassword string `json:"new_password,omitempty"`
+	Days        int    `json:"days"`
+	OwnerID     int64  `json:"owner_id,omitempty"`
+	IpLimit     int    `json:"ip_limit,omitempty"`
+	QuotaGB     int    `json:"quota_gb,omitempty"`
+}
+
+type UserStore struct {
+	Password string `json:"password"`
+	Expired  string `json:"expired"`
+	Status   string `json:"status"`
+	OwnerID  int64  `json:"owner_id,omitempty"`
+	IpLimit  int    `json:"ip_limit,omitempty"`
+	QuotaGB  int    `json:"quota_gb,omitempty"`
+	Created  string `json:"created,omitempty"` // YYYY-MM-DD, empty for accounts created before it was recorded
+}
+
+type Response struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+	Meta    *PageMeta   `json:"meta,omitempty"`
+}
+
+// PageMeta describes the page returned by list endpoints
+type PageMeta struct {
+	Total int `json:"total"` // matches before pagination
+	Page  int `json:"page"`
+	Limit int `json:"limit"` // 0 = everything on one page
+	Pages int `json:"pages"`
+}
+
+// AuditEntry is one line of the append-only audit log
+type AuditEntry struct {
+	Time   string     `json:"time"`
+	Actor  string     `json:"actor"`             // X-Actor header (e.g. "tg:123456") or "api"
+	ApiKey string     `json:"api_key,omitempty"` // fingerprint of the API key used
+	Action string     `json:"action"`
+	Target string     `json:"target"`
+	Before *UserStore `json:"before,omitempty"`
+	After  *UserStore `json:"after,omitempty"`
+	Note   string     `json:"note,omitempty"`
+}
+
+var mutex = &sync.Mutex{}
+
+func main() {
+	// The port chosen by install.sh is the default, so the service needs no flags
+	defaultPort, err := config.LoadApiPort()
+	if err != nil {
+		log.Printf("Using default port: %v", err)
+	}
+	port := flag.Int("port", defaultPort, "Port to run the API server on")
+	flag.Parse()
+
+	key, err := config.LoadApiKey()
+	if err != nil {
+		log.Printf("Using default API key: %v", err)
+	}
+	AuthToken = key
+
+	http.HandleFunc("/api/user/create", authMiddleware(createUser))
+	http.HandleFunc("/api/user/delete", authMiddleware(deleteUser))
+	http.HandleFunc("/api/user/renew", authMiddleware(renewUser))
+	http.HandleFunc("/api/user/password", authMiddleware(changePassword))
+	http.HandleFunc("/api/users", authMiddleware(listUsers))
+	http.HandleFunc("/api/users/export", authMiddleware(exportUsers))
+	http.HandleFunc("/api/users/import", authMiddleware(importUsers))
+	for _, op := range []string{"create", "extend", "delete", "lock", "unlock", "compensate"} {
+		http.HandleFunc("/api/users/bulk/"+op, authMiddleware(bulkHandler(op)))
+	}
+	http.HandleFunc("/api/info", authMiddleware(getSystemInfo))
+	http.HandleFunc("/api/cron/expire", authMiddleware(checkExpiration))
+	http.HandleFunc("/api/audit", authMiddleware(auditHandler))
+
+	log.Printf("Server started at :%d", *port)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *port), nil))
+}
+
+func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-API-Key")
+		if token != AuthToken {
+			jsonResponse(w, http.StatusUnauthorized, false, "Unauthorized", nil)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func jsonResponse(w http.ResponseWriter, status int, success bool, message string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Response{
+		Success: success,
+		Message: message,
+		Data:    data,
+	})
+}
+
+func createUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResponse(w, http.StatusMethodNotAllowed, false, "Method not allowed", nil)
+		return
+	}
+
+	var req UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, http.StatusBadRequest, false, "Invalid request body", nil)
+		return
+	}
+
+	if req.Password == "" || req.Days <= 0 {
+		jsonResponse(w, http.StatusBadRequest, false, "Password dan days harus valid", nil)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	config, err := loadConfig()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca config", nil)
+		return
+	}
+
+	for _, p := range config.Auth.Config {
+		if p == req.Password {
+			jsonResponse(w, http.StatusConflict, false, "User sudah ada", nil)
+			return
+		}
+	}
+
+	config.Auth.Config = append(config.Auth.Config, req.Password)
+	if err := saveConfig(config); err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal menyimpan config", nil)
+		return
+	}
+
+	expDate := time.Now().Add(time.Duration(req.Days) * 24 * time.Hour).Format("2006-01-02")
+
+	users, err := loadUsers()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca database user", nil)
+		return
+	}
+
+	newUser := UserStore{
+		Password: req.Password,
+		Expired:  expDate,
+		Status:   "active",
+		OwnerID:  req.OwnerID,
+		IpLimit:  req.IpLimit,
+		QuotaGB:  req.QuotaGB,
+		Created:  time.Now().Format("2006-01-02"),
+	}
+	users = append(users, newUser)
+
+	if err := saveUsers(users); err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal menyimpan database user", nil)
+		return
+	}
+	writeAudit(r, "create", req.Password, nil, &newUser, "")
+
+	if err := restartService(); err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal merestart service", nil)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, true, "User berhasil dibuat", map[string]string{
+		"password": req.Password,
+		"expired":  expDate,
+		"domain":   loadDomain(),
+	})
+}
+
+func deleteUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResponse(w, http.StatusMethodNotAllowed, false, "Method not allowed", nil)
+		return
+	}
+
+	var req UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, http.StatusBadRequest, false, "Invalid request body", nil)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !checkOwner(w, r, req) {
+		return
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca config", nil)
+		return
+	}
+
+	foundInConfig := false
+	newConfigAuth := []string{}
+	for _, p := range config.Auth.Config {
+		if p == req.Password {
+			foundInConfig = true
+		} else {
+			newConfigAuth = append(newConfigAuth, p)
+		}
+	}
+
+	if foundInConfig {
+		config.Auth.Config = newConfigAuth
+		if err := saveConfig(config); err != nil {
+			jsonResponse(w, http.StatusInternalServerError, false, "Gagal menyimpan config", nil)
+			return
+		}
+	}
+
+	users, err := loadUsers()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca database user", nil)
+		return
+	}
+
+	foundInDB := false
+	var before *UserStore
+	newUsers := []UserStore{}
+	for _, u := range users {
+		if u.Password == req.Password {
+			foundInDB = true
+			deleted := u
+			before = &deleted
+			continue
+		}
+		newUsers = append(newUsers, u)
+	}
+
+	if !foundInConfig && !foundInDB {
+		jsonResponse(w, http.StatusNotFound, false, "User tidak ditemukan", nil)
+		return
+	}
+
+	if foundInDB {
+		if err := saveUsers(newUsers); err != nil {
+			jsonResponse(w, http.StatusInternalServerError, false, "Gagal menyimpan database user", nil)
+			return
+		}
+	}
+	writeAudit(r, "delete", req.Password, before, nil, "")
+
+	if foundInConfig {
+		if err := restartService(); err != nil {
+			jsonResponse(w, http.StatusInternalServerError, false, "Gagal merestart service", nil)
+			return
+		}
+	}
+
+	jsonResponse(w, http.StatusOK, true, "User berhasil dihapus", nil)
+}
+
+func renewUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResponse(w, http.StatusMethodNotAllowed, false, "Method not allowed", nil)
+		return
+	}
+
+	var req UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, http.StatusBadRequest, false, "Invalid request body", nil)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !checkOwner(w, r, req) {
+		return
+	}
+
+	users, err := loadUsers()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca database user", nil)
+		return
+	}
+
+	found := false
+	newUsers := []UserStore{}
+	var newExpDate string
+	var before, after UserStore
+
+	for _, u := range users {
+		if u.Password == req.Password {
+			found = true
+			before = u
+			currentExp, err := time.Parse("2006-01-02", u.Expired)
+			if err != nil {
+				currentExp = time.Now()
+			}
+			
+			if currentExp.Before(time.Now()) {
+				currentExp = time.Now()
+			}
+
+			newExp := currentExp.Add(time.Duration(req.Days) * 24 * time.Hour)
+			newExpDate = newExp.Format("2006-01-02")
+			
+			u.Expired = newExpDate
+			
+			if u.Status == "locked" {
+				u.Status = "active"
+				go enableUser(req.Password)
+			}
+
+			after = u
+			newUsers = append(newUsers, u)
+		} else {
+			newUsers = append(newUsers, u)
+		}
+	}
+
+	if !found {
+		jsonResponse(w, http.StatusNotFound, false, "User tidak ditemukan di database", nil)
+		return
+	}
+
+	if err := saveUsers(newUsers); err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal menyimpan database user", nil)
+		return
+	}
+	writeAudit(r, "renew", req.Password, &before, &after, fmt.Sprintf("+%d hari", req.Days))
+
+	if err := restartService(); err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal merestart service", nil)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, true, "User berhasil diperpanjang", map[string]string{
+		"password": req.Password,
+		"expired":  newExpDate,
+	})
+}
+
+func changePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResponse(w, http.StatusMethodNotAllowed, false, "Method not allowed", nil)
+		return
+	}
+
+	var req UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, http.StatusBadRequest, false, "Invalid request body", nil)
+		return
+	}
+
+	if req.Password == "" || req.NewPassword == "" || req.Password == req.NewPassword {
+		jsonResponse(w, http.StatusBadRequest, false, "Password lama dan baru harus valid", nil)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !checkOwner(w, r, req) {
+		return
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca config", nil)
+		return
+	}
+
+	users, err := loadUsers()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca database user", nil)
+		return
+	}
+
+	for _, p := range config.Auth.Config {
+		if p == req.NewPassword {
+			jsonResponse(w, http.StatusConflict, false, "Password baru sudah dipakai", nil)
+			return
+		}
+	}
+
+	found := -1
+	for i, u := range users {
+		if u.Password == req.NewPassword {
+			jsonResponse(w, http.StatusConflict, false, "Password baru sudah dipakai", nil)
+			return
+		}
+		if u.Password == req.Password {
+			found = i
+		}
+	}
+
+	if found < 0 {
+		jsonResponse(w, http.StatusNotFound, false, "User tidak ditemukan di database", nil)
+		return
+	}
+
+	// Locked users are not in config, they only change in the database
+	inConfig := false
+	for i, p := range config.Auth.Config {
+		if p == req.Password {
+			config.Auth.Config[i] = req.NewPassword
+			inConfig = true
+		}
+	}
+
+	before := users[found]
+	users[found].Password = req.NewPassword
+	if err := saveUsers(users); err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal menyimpan database user", nil)
+		return
+	}
+	writeAudit(r, "password", req.Password, &before, &users[found], "")
+
+	if inConfig {
+		if err := saveConfig(config); err != nil {
+			jsonResponse(w, http.StatusInternalServerError, false, "Gagal menyimpan config", nil)
+			return
+		}
+		if err := restartService(); err != nil {
+			jsonResponse(w, http.StatusInternalServerError, false, "Gagal merestart service", nil)
+			return
+		}
+	}
+
+	jsonResponse(w, http.StatusOK, true, "Password berhasil diganti", map[string]string{
+		"password": req.NewPassword,
+		"expired":  users[found].Expired,
+	})
+}
+
+// userFilter holds the query of GET /api/users. Zero values match everything.
+type userFilter struct {
+	OwnerID      int64
+	Status       string // active, locked or expired
+	ExpiringDays int    // active and expiring within N days
+	CreatedFrom  string
+	CreatedTo    string
+	Search       string // case-insensitive substring of the password
+	Sort         string // password, expired, created, status or owner; "-" prefix for descending
+	Page         int
+	Limit        int
+}
+
+var userSortKeys = map[string]bool{"password": true, "expired": true, "created": true, "status": true, "owner": true}
+
+func parseUserFilter(q url.Values) (userFilter, error) {
+	f := userFilter{
+		Status:      strings.ToLower(q.Get("status")),
+		CreatedFrom: q.Get("created_from"),
+		CreatedTo:   q.Get("created_to"),
+		Search:      strings.ToLower(strings.TrimSpace(q.Get("q"))),
+		Sort:        q.Get("sort"),
+		Page:        1,
+	}
+
+	if v := q.Get("owner_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return f, fmt.Errorf("owner_id tidak valid")
+		}
+		f.OwnerID = id
+	}
+	switch f.Status {
+	case "", "active", "locked", "expired":
+	default:
+		return f, fmt.Errorf("status harus active, locked atau expired")
+	}
+	if v := q.Get("expiring_days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 3650 {
+			return f, fmt.Errorf("expiring_days tidak valid")
+		}
+		f.ExpiringDays = n
+	}
+	for _, d := range []string{f.CreatedFrom, f.CreatedTo} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return f, fmt.Errorf("Format tanggal harus YYYY-MM-DD")
+		}
+	}
+	if f.Sort != "" && !userSortKeys[strings.TrimPrefix(f.Sort, "-")] {
+		return f, fmt.Errorf("sort harus password, expired, created, status atau owner")
+	}
+
+	// Without page/limit every match is returned, as before pagination existed
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return f, fmt.Errorf("page tidak valid")
+		}
+		f.Page = n
+		f.Limit = 50
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			return f, fmt.Errorf("limit tidak valid (1-500)")
+		}
+		f.Limit = n
+	}
+	return f, nil
+}
+
+// userStatus returns the status shown to clients
+func userStatus(u UserStore, today string) string {
+	if u.Status == "locked" {
+		return "Locked"
+	}
+	if u.Expired < today {
+		return "Expired"
+	}
+	return "Active"
+}
+
+func (f userFilter) match(u UserStore, status string) bool {
+	if f.OwnerID != 0 && u.OwnerID != f.OwnerID {
+		return false
+	}
+	if f.Status != "" && strings.ToLower(status) != f.Status {
+		return false
+	}
+	if f.ExpiringDays > 0 {
+		limit := time.Now().AddDate(0, 0, f.ExpiringDays).Format("2006-01-02")
+		if status != "Active" || u.Expired > limit {
+			return false
+		}
+	}
+	if f.CreatedFrom != "" && (u.Created == "" || u.Created < f.CreatedFrom) {
+		return false
+	}
+	if f.CreatedTo != "" && (u.Created == "" || u.Created > f.CreatedTo) {
+		return false
+	}
+	if f.Search != "" && !strings.Contains(strings.ToLower(u.Password), f.Search) {
+		return false
+	}
+	return true
+}
+
+func listUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		jsonResponse(w, http.StatusMethodNotAllowed, false, "Method not allowed", nil)
+		return
+	}
+
+	filter, err := parseUserFilter(r.URL.Query())
+	if err != nil {
+		jsonResponse(w, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	users, err := loadUsers()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca database user", nil)
+		return
+	}
+
+	type UserInfo struct {
+		Password string `json:"password"`
+		Expired  string `json:"expired"`
+		Status   string `json:"status"`
+		OwnerID  int64  `json:"owner_id,omitempty"`
+		IpLimit  int    `json:"ip_limit,omitempty"`
+		QuotaGB  int    `json:"quota_gb,omitempty"`
+		Created  string `json:"created,omitempty"`
+	}
+
+	userList := []UserInfo{}
+	today := time.Now().Format("2006-01-02")
+
+	for _, u := range users {
+		status := userStatus(u, today)
+		if !filter.match(u, status) {
+			continue
+		}
+		userList = append(userList, UserInfo{
+			Password: u.Password,
+			Expired:  u.Expired,
+			Status:   status,
+			OwnerID:  u.OwnerID,
+			IpLimit:  u.IpLimit,
+			QuotaGB:  u.QuotaGB,
+			Created:  u.Created,
+		})
+	}
+
+	if filter.Sort != "" {
+		key := strings.TrimPrefix(filter.Sort, "-")
+		desc := strings.HasPrefix(filter.Sort, "-")
+		sort.SliceStable(userList, func(i, j int) bool {
+			a, b := userList[i], userList[j]
+			if desc {
+				a, b = b, a
+			}
+			switch key {
+			case "expired":
+				return a.Expired < b.Expired
+			case "created":
+				return a.Created < b.Created
+			case "status":
+				return a.Status < b.Status
+			case "owner":
+				return a.OwnerID < b.OwnerID
+			default:
+				return strings.ToLower(a.Password) < strings.ToLower(b.Password)
+			}
+		})
+	}
+
+	meta := PageMeta{Total: len(userList), Page: filter.Page, Limit: filter.Limit, Pages: 1}
+	if filter.Limit > 0 {
+		meta.Pages = (len(userList) + filter.Limit - 1) / filter.Limit
+		if meta.Pages == 0 {
+			meta.Pages = 1
+		}
+		start := (filter.Page - 1) * filter.Limit
+		if start > len(userList) {
+			start = len(userList)
+		}
+		end := start + filter.Limit
+		if end > len(userList) {
+			end = len(userList)
+		}
+		userList = userList[start:end]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "Daftar user",
+		Data:    userList,
+		Meta:    &meta,
+	})
+}
+
+// bulkRequest is the body of the bulk endpoints, each uses a subset:
+// create uses the account fields, extend uses Days (added to the expiry),
+// delete uses Days (accounts expired more than Days ago) and compensate uses
+// From, To, Reason and optionally Days instead of the outage length.
+type bulkRequest struct {
+	Prefix  string `json:"prefix"`
+	Count   int    `json:"count"`
+	Days    int    `json:"days"`
+	OwnerID int64  `json:"owner_id,omitempty"`
+	IpLimit int    `json:"ip_limit,omitempty"`
+	QuotaGB int    `json:"quota_gb,omitempty"`
+	From    string `json:"from,omitempty"` // outage start, "YYYY-MM-DD HH:MM" or "YYYY-MM-DD"
+	To      string `json:"to,omitempty"`   // outage end
+	Reason  string `json:"reason,omitempty"`
+}
+
+// bulkResult is the data of every bulk response
+type bulkResult struct {
+	Count    int         `json:"count"`
+	DryRun   bool        `json:"dry_run,omitempty"`
+	Accounts []UserStore `json:"accounts"` // state after the change, before it for delete
+	Domain   string      `json:"domain,omitempty"`
+	Days     int         `json:"days,omitempty"` // days granted by compensate
+}
+
+// accountChange is one account changed by a bulk operation or an import,
+// kept for the audit log
+type accountChange struct {
+	action        string
+	before, after *UserStore
+}
+
+const (
+	bulkMaxCreate = 100
+	bulkMaxDays   = 3650
+	// Suffix alphabet without look-alikes (0/o, 1/l)
+	bulkAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+)
+
+var bulkPrefixPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,12}$`)
+
+// empty reports whether f selects every account
+func (f userFilter) empty() bool {
+	return f.OwnerID == 0 && f.Status == "" && f.ExpiringDays == 0 &&
+		f.CreatedFrom == "" && f.CreatedTo == "" && f.Search == ""
+}
+
+// bulkHandler serves POST /api/users/bulk/<op>. Accounts are selected with
+// the query parameters of GET /api/users and dry_run=1 only reports them.
+// All changes are written at once, the server config at most once, followed
+// by at most one restart.
+func bulkHandler(op string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			jsonResponse(w, http.StatusMethodNotAllowed, false, "Method not allowed", nil)
+			return
+		}
+		if !checkAdmin(w, r) {
+			return
+		}
+
+		q := r.URL.Query()
+		filter, err := parseUserFilter(q)
+		if err != nil {
+			jsonResponse(w, http.StatusBadRequest, false, err.Error(), nil)
+			return
+		}
+		var req bulkRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			jsonResponse(w, http.StatusBadRequest, false, "Invalid request body", nil)
+			return
+		}
+		var outage outageWindow
+		if op == "compensate" {
+			if outage, err = parseOutage(req.From, req.To); err != nil {
+				jsonResponse(w, http.StatusBadRequest, false, err.Error(), nil)
+				return
+			}
+			if req.Days == 0 {
+				req.Days = outage.days()
+			}
+		}
+		if msg := validateBulk(op, req, filter, q.Get("all") == "1"); msg != "" {
+			jsonResponse(w, http.StatusBadRequest, false, msg, nil)
+			return
+		}
+		dryRun := q.Get("dry_run") == "1"
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		config, err := loadConfig()
+		if err != nil {
+			jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca config", nil)
+			return
+		}
+		users, err := loadUsers()
+		if err != nil {
+			jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca database user", nil)
+			return
+		}
+
+		auth := make(map[string]bool)
+		for _, p := range config.Auth.Config {
+			auth[p] = true
+		}
+
+		var changes []accountChange
+		var message string
+		switch op {
+		case "create":
+			users, changes, err = bulkCreate(users, auth, req)
+			message = "%d akun dibuat"
+		case "extend":
+			changes = bulkExtend(users, filter, req.Days)
+			message = "%d akun diperpanjang"
+		case "delete":
+			users, changes = bulkDelete(users, auth, filter, req.Days)
+			message = "%d akun dihapus"
+		case "lock":
+			changes = bulkLock(users, auth, filter)
+			message = "%d akun dikunci"
+		case "unlock":
+			changes = bulkUnlock(users, auth, filter)
+			message = "%d akun dibuka"
+		case "compensate":
+			changes = bulkCompensate(users, auth, filter, outage, req.Days)
+			message = "%d akun mendapat kompensasi"
+		}
+		if err != nil {
+			jsonResponse(w, http.StatusInternalServerError, false, err.Error(), nil)
+			return
+		}
+
+		result := bulkResult{Count: len(changes), DryRun: dryRun, Accounts: []UserStore{}}
+		for _, c := range changes {
+			if c.after != nil {
+				result.Accounts = append(result.Accounts, *c.after)
+			} else {
+				result.Accounts = append(result.Accounts, *c.before)
+			}
+		}
+		switch op {
+		case "create":
+			result.Domain = loadDomain()
+		case "compensate":
+			result.Days = req.Days
+		}
+		message = fmt.Sprintf(message, len(changes))
+		if dryRun {
+			jsonResponse(w, http.StatusOK, true, "Simulasi: "+message, result)
+			return
+		}
+		if len(changes) == 0 {
+			jsonResponse(w, http.StatusOK, true, message, result)
+			return
+		}
+
+		note := "bulk"
+		switch op {
+		case "extend":
+			note = fmt.Sprintf("bulk +%d hari", req.Days)
+		case "compensate":
+			note = fmt.Sprintf("gangguan %s: +%d hari", outage, req.Days)
+			if reason := strings.TrimSpace(req.Reason); reason != "" {
+				note += " (" + reason + ")"
+			}
+		}
+		if !commitUsers(w, r, config, auth, users, changes, note) {
+			return
+		}
+		jsonResponse(w, http.StatusOK, true, message, result)
+	}
+}
+
+// commitUsers stores users and writes the audit entries of changes. auth is
+// the set of passwords that must be enabled; when it differs from the server
+// config, the config is written once and the service restarted once. On
+// failure the error response is written and false returned. Caller holds mutex.
+func commitUsers(w http.ResponseWriter, r *http.Request, config Config, auth map[string]bool, users []UserStore, changes []accountChange, note string) bool {
+	configChanged := false
+	newAuth := []string{}
+	for _, p := range config.Auth.Config {
+		if auth[p] {
+			newAuth = append(newAuth, p)
+			delete(auth, p)
+		} else {
+			configChanged = true
+		}
+	}
+	for _, u := range users {
+		if auth[u.Password] {
+			newAuth = append(newAuth, u.Password)
+			configChanged = true
+		}
+	}
+
+	if configChanged {
+		config.Auth.Config = newAuth
+		if err := saveConfig(config); err != nil {
+			jsonResponse(w, http.StatusInternalServerError, false, "Gagal menyimpan config", nil)
+			return false
+		}
+	}
+	if err := saveUsers(users); err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal menyimpan database user", nil)
+		return false
+	}
+	for _, c := range changes {
+		target := ""
+		if c.after != nil {
+			target = c.after.Password
+		} else {
+			target = c.before.Password
+		}
+		writeAudit(r, c.action, target, c.before, c.after, note)
+	}
+
+	if configChanged {
+		if err := restartService(); err != nil {
+			jsonResponse(w, http.StatusInternalServerError, false, "Gagal merestart service", nil)
+			return false
+		}
+	}
+	return true
+}
+
+// validateBulk checks the request before the stores are locked, returning
+// the error message or ""
+func validateBulk(op string, req bulkRequest, filter userFilter, all bool) string {
+	switch op {
+	case "create":
+		if !bulkPrefixPattern.MatchString(req.Prefix) {
+			return "prefix harus 1-12 karakter: huruf, angka, - atau _"
+		}
+		if req.Count < 1 || req.Count > bulkMaxCreate {
+			return fmt.Sprintf("count harus 1-%d", bulkMaxCreate)
+		}
+		if req.Days < 1 || req.Days > bulkMaxDays {
+			return fmt.Sprintf("days harus 1-%d", bulkMaxDays)
+		}
+	case "extend", "compensate":
+		if req.Days < 1 || req.Days > bulkMaxDays {
+			return fmt.Sprintf("days harus 1-%d", bulkMaxDays)
+		}
+	case "delete":
+		if req.Days < 0 || req.Days > bulkMaxDays {
+			return fmt.Sprintf("days harus 0-%d", bulkMaxDays)
+		}
+	case "lock", "unlock":
+		// Guard against locking everyone because a filter was forgotten
+		if filter.empty() && !all {
+			return "Filter wajib diisi, atau kirim all=1 untuk semua akun"
+		}
+	}
+	return ""
+}
+
+// bulkCreate adds req.Count accounts named prefix + random suffix
+func bulkCreate(users []UserStore, auth map[string]bool, req bulkRequest) ([]UserStore, []accountChange, error) {
+	taken := make(map[string]bool)
+	for p := range auth {
+		taken[p] = true
+	}
+	for _, u := range users {
+		taken[u.Password] = true
+	}
+
+	expDate := time.Now().AddDate(0, 0, req.Days).Format("2006-01-02")
+	var changes []accountChange
+	for len(changes) < req.Count {
+		suffix, err := randomSuffix(6)
+		if err != nil {
+			return users, nil, fmt.Errorf("Gagal membuat password")
+		}
+		password := req.Prefix + suffix
+		if taken[password] {
+			continue
+		}
+		taken[password] = true
+		auth[password] = true
+
+		u := UserStore{
+			Password: password,
+			Expired:  expDate,
+			Status:   "active",
+			OwnerID:  req.OwnerID,
+			IpLimit:  req.IpLimit,
+			QuotaGB:  req.QuotaGB,
+			Created:  time.Now().Format("2006-01-02"),
+		}
+		users = append(users, u)
+		changes = append(changes, accountChange{action: "create", after: &u})
+	}
+	return users, changes, nil
+}
+
+// bulkExtend adds days to the expiry of the matching accounts that are still
+// active, e.g. to make up for an outage. Locked and expired accounts are
+// left alone, those need a regular renew.
+func bulkExtend(users []UserStore, filter userFilter, days int) []accountChange {
+	today := time.Now().Format("2006-01-02")
+	var changes []accountChange
+	for i, u := range users {
+		if status := userStatus(u, today); status != "Active" || !filter.match(u, status) {
+			continue
+		}
+		exp, err := time.Parse("2006-01-02", u.Expired)
+		if err != nil {
+			continue
+		}
+		before := u
+		users[i].Expired = exp.AddDate(0, 0, days).Format("2006-01-02")
+		after := users[i]
+		changes = append(changes, accountChange{action: "renew", before: &before, after: &after})
+	}
+	return changes
+}
+
+// bulkDelete removes the matching accounts that expired more than days ago
+func bulkDelete(users []UserStore, auth map[string]bool, filter userFilter, days int) ([]UserStore, []accountChange) {
+	today := time.Now().Format("2006-01-02")
+	cutoff := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+	kept := []UserStore{}
+	var changes []accountChange
+	for _, u := range users {
+		if u.Expired >= cutoff || !filter.match(u, userStatus(u, today)) {
+			kept = append(kept, u)
+			continue
+		}
+		deleted := u
+		delete(auth, u.Password)
+		changes = append(changes, accountChange{action: "delete", before: &deleted})
+	}
+	return kept, changes
+}
+
+// bulkLock locks the matching accounts that are not locked yet
+func bulkLock(users []UserStore, auth map[string]bool, filter userFilter) []accountChange {
+	today := time.Now().Format("2006-01-02")
+	var changes []accountChange
+	for i, u := range users {
+		if u.Status == "locked" || !filter.match(u, userStatus(u, today)) {
+			continue
+		}
+		before := u
+		users[i].Status = "locked"
+		delete(auth, u.Password)
+		after := users[i]
+		changes = append(changes, accountChange{action: "lock", before: &before, after: &after})
+	}
+	return changes
+}
+
+// bulkUnlock unlocks the matching locked accounts. Expired ones stay locked
+// because the expiration check would lock them again; renew those instead.
+func bulkUnlock(users []UserStore, auth map[string]bool, filter userFilter) []accountChange {
+	today := time.Now().Format("2006-01-02")
+	var changes []accountChange
+	for i, u := range users {
+		if u.Status != "locked" || u.Expired < today || !filter.match(u, userStatus(u, today)) {
+			continue
+		}
+		before := u
+		users[i].Status = "active"
+		auth[u.Password] = true
+		after := users[i]
+		changes = append(changes, accountChange{action: "unlock", before: &before, after: &after})
+	}
+	return changes
+}
+
+// outageWindow is the period a compensation makes up for
+type outageWindow struct {
+	From, To time.Time
+}
+
+// parseOutage reads the outage start and end in server time
+func parseOutage(from, to string) (outageWindow, error) {
+	var w outageWindow
+	var err error
+	if w.From, err = parseOutageTime(from); err != nil {
+		return w, fmt.Errorf("from harus YYYY-MM-DD HH:MM atau YYYY-MM-DD")
+	}
+	if w.To, err = parseOutageTime(to); err != nil {
+		return w, fmt.Errorf("to harus YYYY-MM-DD HH:MM atau YYYY-MM-DD")
+	}
+	if !w.To.After(w.From) {
+		return w, fmt.Errorf("to harus setelah from")
+	}
+	if w.To.After(time.Now()) {
+		return w, fmt.Errorf("to tidak boleh di masa depan")
+	}
+	return w, nil
+}
+
+func parseOutageTime(value string) (time.Time, error) {
+	value = strings.Replace(strings.TrimSpace(value), "T", " ", 1)
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// days is the lost time rounded up to whole days, as expiry dates have no time
+func (w outageWindow) days() int {
+	return int((w.To.Sub(w.From) + 24*time.Hour - 1) / (24 * time.Hour))
+}
+
+func (w outageWindow) String() string {
+	return w.From.Format("2006-01-02 15:04") + " s/d " + w.To.Format("2006-01-02 15:04")
+}
+
+// bulkCompensate extends the matching accounts that were usable during the
+// outage: not locked, created before it ended and not expired before it
+// began. Accounts that expired since then get the days added to their old
+// expiry and are enabled again when that date is still ahead.
+func bulkCompensate(users []UserStore, auth map[string]bool, filter userFilter, outage outageWindow, days int) []accountChange {
+	today := time.Now().Format("2006-01-02")
+	start := outage.From.Format("2006-01-02")
+	end := outage.To.Format("2006-01-02")
+	var changes []accountChange
+	for i, u := range users {
+		if u.Status == "locked" || u.Expired < start || (u.Created != "" && u.Created > end) ||
+			!filter.match(u, userStatus(u, today)) {
+			continue
+		}
+		exp, err := time.Parse("2006-01-02", u.Expired)
+		if err != nil {
+			continue
+		}
+		before := u
+		users[i].Expired = exp.AddDate(0, 0, days).Format("2006-01-02")
+		if users[i].Expired >= today {
+			auth[u.Password] = true
+		}
+		after := users[i]
+		changes = append(changes, accountChange{action: "compensate", before: &before, after: &after})
+	}
+	return changes
+}
+
+// randomSuffix returns n characters from bulkAlphabet
+func randomSuffix(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = bulkAlphabet[int(b)%len(bulkAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// exportColumns is the CSV layout written by export and expected by import
+var exportColumns = []string{"password", "expired", "status", "owner_id", "ip_limit", "quota_gb", "created"}
+
+// exportUsers serves GET /api/users/export?format=csv|json. Accounts are
+// selected with the query parameters of GET /api/users. JSON uses the
+// users.json layout, so both formats can be imported again.
+func exportUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		jsonResponse(w, http.StatusMethodNotAllowed, false, "Method not allowed", nil)
+		return
+	}
+
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		jsonResponse(w, http.StatusBadRequest, false, "format harus csv atau json", nil)
+		return
+	}
+	filter, err := parseUserFilter(q)
+	if err != nil {
+		jsonResponse(w, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	mutex.Lock()
+	users, err := loadUsers()
+	mutex.Unlock()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca database user", nil)
+		return
+	}
+
+	today := time.Now().Format("2006-01-02")
+	selected := []UserStore{}
+	for _, u := range users {
+		if filter.match(u, userStatus(u, today)) {
+			selected = append(selected, u)
+		}
+	}
+
+	name := fmt.Sprintf("zivpn-users-%s.%s", time.Now().Format("20060102-150405"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(selected)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	cw := csv.NewWriter(w)
+	cw.Write(exportColumns)
+	for _, u := range selected {
+		cw.Write([]string{
+			u.Password, u.Expired, u.Status, strconv.FormatInt(u.OwnerID, 10),
+			strconv.Itoa(u.IpLimit), strconv.Itoa(u.QuotaGB), u.Created,
+		})
+	}
+	cw.Flush()
+}
+
+// Limits of POST /api/users/import
+const (
+	importMaxBytes  = 5 << 20
+	importMaxRows   = 10000
+	importMaxErrors = 100 // rows listed in the report, the count is always complete
+)
+
+// importPolicies decide what happens to a row whose password already exists:
+// skip keeps the account, overwrite replaces it and merge only takes the
+// later expiry date
+var importPolicies = map[string]bool{"skip": true, "overwrite": true, "merge": true}
+
+var importPasswordPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,20}$`)
+
+// importRow is one account read from an import file
+type importRow struct {
+	Line int
+	User UserStore
+	Err  string
+}
+
+// importError is a rejected row in the import report
+type importError struct {
+	Line     int    `json:"line"`
+	Password string `json:"password,omitempty"`
+	Error    string `json:"error"`
+}
+
+// importReport is the data of POST /api/users/import
+type importReport struct {
+	Total   int           `json:"total"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Skipped int           `json:"skipped"`
+	Invalid int           `json:"invalid"`
+	DryRun  bool          `json:"dry_run,omitempty"`
+	Errors  []importError `json:"errors"`
+}
+
+// importUsers serves POST /api/users/import?format=csv|json&policy=skip|overwrite|merge.
+// The body is the file itself. Invalid rows are reported and left out, the
+// rest is applied with a single config write and one restart; dry_run=1
+// only returns the report.
+func importUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResponse(w, http.StatusMethodNotAllowed, false, "Method not allowed", nil)
+		return
+	}
+	if !checkAdmin(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	policy := q.Get("policy")
+	if policy == "" {
+		policy = "skip"
+	}
+	if !importPolicies[policy] {
+		jsonResponse(w, http.StatusBadRequest, false, "policy harus skip, overwrite atau merge", nil)
+		return
+	}
+	dryRun := q.Get("dry_run") == "1"
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, importMaxBytes))
+	if err != nil {
+		jsonResponse(w, http.StatusRequestEntityTooLarge, false, fmt.Sprintf("File terlalu besar (maks %d MB)", importMaxBytes>>20), nil)
+		return
+	}
+
+	format := q.Get("format")
+	if format == "" {
+		// Without a format the first character decides: JSON files are arrays
+		format = "csv"
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+			format = "json"
+		}
+	}
+	var rows []importRow
+	switch format {
+	case "csv":
+		rows, err = parseImportCSV(body)
+	case "json":
+		rows, err = parseImportJSON(body)
+	default:
+		err = fmt.Errorf("format harus csv atau json")
+	}
+	if err != nil {
+		jsonResponse(w, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+	if len(rows) > importMaxRows {
+		jsonResponse(w, http.StatusBadRequest, false, fmt.Sprintf("Maksimal %d baris per import", importMaxRows), nil)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	config, err := loadConfig()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca config", nil)
+		return
+	}
+	users, err := loadUsers()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca database user", nil)
+		return
+	}
+
+	auth := make(map[string]bool)
+	for _, p := range config.Auth.Config {
+		auth[p] = true
+	}
+	index := make(map[string]int)
+	for i, u := range users {
+		index[u.Password] = i
+	}
+
+	report := importReport{Total: len(rows), DryRun: dryRun, Errors: []importError{}}
+	today := time.Now().Format("2006-01-02")
+	seen := make(map[string]int)
+	var changes []accountChange
+	for _, row := range rows {
+		if row.Err == "" {
+			if first, ok := seen[row.User.Password]; ok {
+				row.Err = fmt.Sprintf("password duplikat dengan baris %d", first)
+			}
+		}
+		if row.Err != "" {
+			report.Invalid++
+			if len(report.Errors) < importMaxErrors {
+				report.Errors = append(report.Errors, importError{Line: row.Line, Password: row.User.Password, Error: row.Err})
+			}
+			continue
+		}
+		seen[row.User.Password] = row.Line
+
+		imported := row.User
+		i, exists := index[imported.Password]
+		if !exists {
+			if imported.Created == "" {
+				imported.Created = today
+			}
+			users = append(users, imported)
+			index[imported.Password] = len(users) - 1
+			after := imported
+			changes = append(changes, accountChange{action: "import", after: &after})
+			report.Created++
+			auth[imported.Password] = imported.Status != "locked" && imported.Expired >= today
+			continue
+		}
+
+		before := users[i]
+		switch policy {
+		case "overwrite":
+			if imported.Created == "" {
+				imported.Created = before.Created
+			}
+			users[i] = imported
+		case "merge":
+			if imported.Expired > before.Expired {
+				users[i].Expired = imported.Expired
+			}
+		}
+		if users[i] == before {
+			report.Skipped++
+			continue
+		}
+		after := users[i]
+		changes = append(changes, accountChange{action: "import", before: &before, after: &after})
+		report.Updated++
+		auth[after.Password] = after.Status != "locked" && after.Expired >= today
+	}
+	// Passwords marked false above must end up disabled
+	for p, enabled := range auth {
+		if !enabled {
+			delete(auth, p)
+		}
+	}
+
+	message := fmt.Sprintf("Import: %d dibuat, %d diperbarui, %d dilewati, %d tidak valid", report.Created, report.Updated, report.Skipped, report.Invalid)
+	if dryRun {
+		jsonResponse(w, http.StatusOK, true, "Simulasi "+message, report)
+		return
+	}
+	if len(changes) > 0 && !commitUsers(w, r, config, auth, users, changes, "import "+policy) {
+		return
+	}
+	jsonResponse(w, http.StatusOK, true, message, report)
+}
+
+// parseImportCSV reads a CSV file whose first row names the columns, in any
+// order. password and expired are required, the other exportColumns optional.
+func parseImportCSV(data []byte) ([]importRow, error) {
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV tidak valid: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("File kosong")
+	}
+
+	// Spreadsheet programs may start the file with a byte order mark
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"password", "expired"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("Kolom %s tidak ada di header CSV", required)
+		}
+	}
+
+	var rows []importRow
+	for n, record := range records[1:] {
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		row := importRow{Line: n + 2}
+		row.User = UserStore{
+			Password: get("password"),
+			Expired:  get("expired"),
+			Status:   get("status"),
+			Created:  get("created"),
+		}
+		for _, field := range []struct {
+			name string
+			set  func(int64)
+		}{
+			{"owner_id", func(v int64) { row.User.OwnerID = v }},
+			{"ip_limit", func(v int64) { row.User.IpLimit = int(v) }},
+			{"quota_gb", func(v int64) { row.User.QuotaGB = int(v) }},
+		} {
+			raw := get(field.name)
+			if raw == "" {
+				continue
+			}
+			v, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || v < 0 {
+				row.Err = field.name + " harus angka positif"
+				break
+			}
+			field.set(v)
+		}
+		if row.Err == "" {
+			row.Err = normalizeImport(&row.User)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseImportJSON reads an array in the users.json layout
+func parseImportJSON(data []byte) ([]importRow, error) {
+	var list []UserStore
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("JSON tidak valid: %v", err)
+	}
+	rows := make([]importRow, len(list))
+	for i, u := range list {
+		rows[i] = importRow{Line: i + 1, User: u}
+		rows[i].Err = normalizeImport(&rows[i].User)
+	}
+	return rows, nil
+}
+
+// normalizeImport validates an imported account and brings it into the
+// stored form, returning the problem or "". "expired" is accepted as a
+// status since the expiry date already says so.
+func normalizeImport(u *UserStore) string {
+	if !importPasswordPattern.MatchString(u.Password) {
+		return "password harus 3-20 karakter: huruf, angka, - atau _"
+	}
+	if _, err := time.Parse("2006-01-02", u.Expired); err != nil {
+		return "expired harus YYYY-MM-DD"
+	}
+	if u.Created != "" {
+		if _, err := time.Parse("2006-01-02", u.Created); err != nil {
+			return "created harus YYYY-MM-DD"
+		}
+	}
+	switch strings.ToLower(u.Status) {
+	case "", "active", "expired":
+		u.Status = "active"
+	case "locked":
+		u.Status = "locked"
+	default:
+		return "status harus active, locked atau expired"
+	}
+	if u.OwnerID < 0 || u.IpLimit < 0 || u.QuotaGB < 0 {
+		return "owner_id, ip_limit dan quota_gb tidak boleh negatif"
+	}
+	return ""
+}
+
+func getSystemInfo(w http.ResponseWriter, r *http.Request) {
+	cmd := exec.Command("curl", "-s", "ifconfig.me")
+	ipPub, _ := cmd.Output()
+
+	cmd = exec.Command("hostname", "-I")
+	ipPriv, _ := cmd.Output()
+
+	info := map[string]string{
+		"domain":     loadDomain(),
+		"public_ip":  strings.TrimSpace(string(ipPub)),
+		"private_ip": strings.Fields(string(ipPriv))[0],
+		"port":       "5667",
+		"service":    "zivpn",
+	}
+
+	jsonResponse(w, http.StatusOK, true, "System Info", info)
+}
+
+func checkExpiration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResponse(w, http.StatusMethodNotAllowed, false, "Method not allowed", nil)
+		return
+	}
+
+	users, err := loadUsers()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca database user", nil)
+		return
+	}
+
+	today := time.Now().Format("2006-01-02")
+	
+	// Load config to check who is currently active
+	config, err := loadConfig()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca config", nil)
+		return
+	}
+
+	activeUsers := make(map[string]bool)
+	for _, p := range config.Auth.Config {
+		activeUsers[p] = true
+	}
+
+	revokedCount := 0
+	for _, u := range users {
+		if u.Expired < today && activeUsers[u.Password] {
+			log.Printf("User %s expired (Exp: %s). Revoking access.\n", u.Password, u.Expired)
+			revokeAccess(u.Password)
+			// users.json keeps the account as is, only its access is revoked,
+			// so the after state is the stored one and the note says why
+			before, after := u, u
+			writeAudit(r, "lock", u.Password, &before, &after, "access revoked, expired "+u.Expired)
+			revokedCount++
+		}
+	}
+
+	jsonResponse(w, http.StatusOK, true, fmt.Sprintf("Expiration check complete. Revoked: %d", revokedCount), nil)
+}
+
+func revokeAccess(password string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	config, err := loadConfig()
+	if err == nil {
+		newConfigAuth := []string{}
+		changed := false
+		for _, p := range config.Auth.Config {
+			if p == password {
+				changed = true
+			} else {
+				newConfigAuth = append(newConfigAuth, p)
+			}
+		}
+		if changed {
+			config.Auth.Config = newConfigAuth
+			saveConfig(config)
+			restartService()
+		}
+	}
+}
+
+func enableUser(password string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	config, err := loadConfig()
+	if err != nil {
+		return
+	}
+
+	exists := false
+	for _, p := range config.Auth.Config {
+		if p == password {
+			exists = true
+			break
+		}
+	}
+
+	if !exists {
+		config.Auth.Config = append(config.Auth.Config, password)
+		saveConfig(config)
+		restartService()
+	}
+}
+
+
+// callerID returns the Telegram ID a bot sent in X-Actor ("tg:<id>"), or 0
+func callerID(r *http.Request) int64 {
+	actor := strings.TrimSpace(r.Header.Get("X-Actor"))
+	if !strings.HasPrefix(actor, "tg:") {
+		return 0
+	}
+	id, _ := strconv.ParseInt(strings.TrimPrefix(actor, "tg:"), 10, 64)
+	return id
+}
+
+// isBotAdmin reports whether userID may manage every account per bot-config.json
+func isBotAdmin(userID int64) bool {
+	var access config.Access
+	if err := storage.ReadJSON(config.BotConfigFile, &access); err != nil {
+		log.Printf("Failed to read bot config: %v", err)
+		return false
+	}
+	return access.Can(userID, config.PermUsers)
+}
+
+// checkOwner rejects changes by a bot user to an account they do not own, or
+// to one not owned by req.OwnerID when it is set. Caller must hold mutex.
+func checkOwner(w http.ResponseWriter, r *http.Request, req UserRequest) bool {
+	owner := req.OwnerID
+	if caller := callerID(r); caller != 0 && !isBotAdmin(caller) {
+		if owner != 0 && owner != caller {
+			jsonResponse(w, http.StatusForbidden, false, "Akun bukan milik Anda", nil)
+			return false
+		}
+		owner = caller
+	}
+	if owner == 0 {
+		return true
+	}
+
+	users, err := loadUsers()
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca database user", nil)
+		return false
+	}
+
+	for _, u := range users {
+		if u.Password == req.Password {
+			if u.OwnerID != owner {
+				jsonResponse(w, http.StatusForbidden, false, "Akun bukan milik Anda", nil)
+				return false
+			}
+			return true
+		}
+	}
+
+	jsonResponse(w, http.StatusNotFound, false, "User tidak ditemukan", nil)
+	return false
+}
+
+// checkAdmin rejects bulk changes made on behalf of a bot user who is not an admin
+func checkAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if caller := callerID(r); caller != 0 && !isBotAdmin(caller) {
+		jsonResponse(w, http.StatusForbidden, false, "Hanya Admin yang boleh melakukan ini", nil)
+		return false
+	}
+	return true
+}
+
+// apiKeyID returns a short fingerprint so the log never contains the key itself
+func apiKeyID(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
+}
+
+// writeAudit appends one entry to AuditLog. Failures are logged, never returned,
+// so a full disk cannot block account changes.
+func writeAudit(r *http.Request, action, target string, before, after *UserStore, note string) {
+	actor := strings.TrimSpace(r.Header.Get("X-Actor"))
+	if actor == "" {
+		actor = "api"
+	}
+	if len(actor) > 64 {
+		actor = actor[:64]
+	}
+
+	entry := AuditEntry{
+		Time:   time.Now().Format("2006-01-02 15:04:05"),
+		Actor:  actor,
+		ApiKey: apiKeyID(r.Header.Get("X-API-Key")),
+		Action: action,
+		Target: target,
+		Before: before,
+		After:  after,
+		Note:   note,
+	}
+	if err := storage.AppendJSONLine(AuditLog, entry); err != nil {
+		log.Printf("Audit write failed: %v", err)
+	}
+}
+
+// auditHandler lists audit entries (GET) or records an event that happened
+// outside the API, such as a restore done by a bot (POST).
+func auditHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		listAudit(w, r)
+	case http.MethodPost:
+		var req struct {
+			Action string `json:"action"`
+			Target string `json:"target"`
+			Note   string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Action == "" {
+			jsonResponse(w, http.StatusBadRequest, false, "Action harus diisi", nil)
+			return
+		}
+		// Prefix keeps client-reported events apart from mutations done by the API
+		writeAudit(r, "client:"+req.Action, req.Target, nil, nil, req.Note)
+		jsonResponse(w, http.StatusOK, true, "Audit dicatat", nil)
+	default:
+		jsonResponse(w, http.StatusMethodNotAllowed, false, "Method not allowed", nil)
+	}
+}
+
+func listAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 50
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			jsonResponse(w, http.StatusBadRequest, false, "limit tidak valid (1-1000)", nil)
+			return
+		}
+		limit = n
+	}
+	from, to := q.Get("from"), q.Get("to")
+	for _, d := range []string{from, to} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			jsonResponse(w, http.StatusBadRequest, false, "Format tanggal harus YYYY-MM-DD", nil)
+			return
+		}
+	}
+
+	var entries []AuditEntry
+	err := storage.ScanJSONLines(AuditLog, func(line []byte) bool {
+		var e AuditEntry
+		if json.Unmarshal(line, &e) != nil {
+			return true
+		}
+		day := e.Time
+		if len(day) >= 10 {
+			day = day[:10]
+		}
+		if (q.Get("actor") != "" && e.Actor != q.Get("actor")) ||
+			(q.Get("action") != "" && e.Action != q.Get("action")) ||
+			(q.Get("target") != "" && e.Target != q.Get("target")) ||
+			(from != "" && day < from) || (to != "" && day > to) {
+			return true
+		}
+		entries = append(entries, e)
+		return true
+	})
+	if err != nil {
+		jsonResponse(w, http.StatusInternalServerError, false, "Gagal membaca audit log", nil)
+		return
+	}
+
+	// Newest first, capped at limit
+	result := []AuditEntry{}
+	for i := len(entries) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, entries[i])
+	}
+	jsonResponse(w, http.StatusOK, true, "Audit log", result)
+}
+
+func loadConfig() (Config, error) {
+	var config Config
+	err := storage.ReadJSON(ConfigFile, &config)
+	return config, err
+}
+
+func saveConfig(config Config) error {
+	return storage.WriteJSON(ConfigFile, config, 0644)
+}
+
+func loadUsers() ([]UserStore, error) {
+	var users []UserStore
+	err := storage.LoadJSON(UserDB, &users)
+	return users, err
+}
+
+func saveUsers(users []UserStore) error {
+	return storage.WriteJSON(UserDB, users, 0644)
+}
+
+// loadDomain returns the domain shown in responses
+func loadDomain() string {
+	if domain := config.LoadDomain(); domain != "" {
+		return domain
+	}
+	return "Tidak diatur"
+}
+
+func restartService() error {
+	cmd := exec.Command("systemctl", "restart", "zivpn.service")
+	return cmd.Run()
+}
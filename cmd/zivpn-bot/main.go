@@ -0,0 +1,1384 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zivpn/internal/apiclient"
+	"zivpn/internal/backup"
+	"zivpn/internal/botui"
+	"zivpn/internal/bulk"
+	"zivpn/internal/config"
+	"zivpn/internal/flow"
+	"zivpn/internal/session"
+	"zivpn/internal/storage"
+	"zivpn/internal/telegram"
+)
+
+// ==========================================
+// Konstanta & Konfigurasi File Path
+// ==========================================
+
+const (
+	// Base Directory untuk semua konfigurasi ZiVPN
+	ConfigDir     = config.Dir
+	BotConfigFile = config.BotConfigFile
+	ApiPortFile   = config.ApiPortFile
+	ApiKeyFile    = config.ApiKeyFile
+	DomainFile    = config.DomainFile
+	AdminLogFile  = config.AdminLogFile
+	SnapshotDir   = config.SnapshotDir
+	SessionFile   = ConfigDir + "/bot-sessions.json"
+
+	// DefaultSessionTTL dipakai jika session_ttl tidak diisi
+	DefaultSessionTTL = 15 * time.Minute
+	// PortFile tidak digunakan karena port dibaca dari ApiPortFile
+)
+
+// Izin Admin. Owner (AdminID) selalu memiliki semua izin.
+const (
+	PermUsers  = config.PermUsers  // kelola akun milik siapa saja, daftar user, blokir
+	PermBackup = config.PermBackup // backup & restore
+	PermMode   = config.PermMode   // ubah mode public/private
+	PermSales  = config.PermSales  // lihat laporan penjualan (Paid Bot)
+	PermPrices = config.PermPrices // kelola harga (Paid Bot)
+)
+
+var allPermissions = config.AllPermissions
+
+// ==========================================
+// Variabel Global
+// ==========================================
+
+var (
+	// ApiUrl akan diisi saat startup berdasarkan ApiPortFile
+	ApiUrl = config.ApiURL(config.DefaultApiPort) // Default fallback
+	// ApiKey akan diisi saat startup berdasarkan ApiKeyFile
+	ApiKey = ""
+	// api adalah client API ZiVPN, dibuat setelah ApiUrl dan ApiKey dimuat
+	api *apiclient.Client
+)
+
+// ==========================================
+// Struktur Data
+// ==========================================
+
+type BotConfig struct {
+	config.Access // AdminID (owner) dan Admins tambahan
+
+	BotToken string `json:"bot_token"`
+	Mode     string `json:"mode"`   // "public" or "private"
+	Domain   string `json:"domain"` // Domain dari setup
+
+	// Batasan untuk user non-admin (0 = tanpa batas)
+	MaxAccounts     int     `json:"max_accounts"`
+	MaxDays         int     `json:"max_days"`
+	CreateCooldown  int     `json:"create_cooldown"`  // Jeda antar pembuatan akun (menit)
+	RequiredChannel string  `json:"required_channel"` // @username atau ID channel yang wajib diikuti
+	BannedUsers     []int64 `json:"banned_users"`
+
+	SessionTTL      int  `json:"session_ttl"`      // Batas waktu sesi tanpa aktivitas (menit), default 15
+	PersistSessions bool `json:"persist_sessions"` // Simpan sesi ke file agar tidak hilang saat restart
+
+	backup.Schedule // Backup otomatis: backup_schedule, backup_keep, backup_chat_id
+}
+
+// Admin adalah admin tambahan dengan sebagian izin
+type Admin = config.Admin
+
+type IpInfo struct {
+	City  string `json:"city"`
+	Isp   string `json:"isp"`
+	Query string `json:"query"` // IP Address
+}
+
+// ==========================================
+// State Global
+// ==========================================
+
+// sessions menyimpan state percakapan dan pesan terakhir per chat.
+// Diinisialisasi di main setelah konfigurasi dimuat.
+var sessions *session.Manager
+
+// ui menampilkan layar yang sama dengan Paid Bot (Akun Saya, perintah massal,
+// import/export, audit log, backup & restore)
+var ui *botui.UI
+
+// flows menjalankan alur percakapan (buat, perpanjang, restore) di atas sessions
+var flows *flow.Engine
+
+// botConfig adalah konfigurasi aktif. Handler di goroutine hanya membaca
+// salinan yang didapat dari currentConfig; perubahan dibuat pada salinan baru
+// oleh updateConfig lalu ditukar, sehingga salinan lama tidak pernah diubah.
+var botConfig atomic.Pointer[BotConfig]
+var configMutex sync.Mutex // Mengurutkan updateConfig
+
+// Waktu pembuatan akun terakhir per user, untuk cooldown (tidak persisten)
+var lastCreateAt = make(map[int64]time.Time)
+var limitMutex sync.Mutex
+
+// ==========================================
+// Main Entry Point
+// ==========================================
+
+func main() {
+	log.Println("Starting ZiVPN Telegram Bot...")
+
+	// 1. Setup API Key and URL
+	setupAPIConfig()
+
+	// 2. Load Bot Configuration
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("FATAL: Gagal memuat konfigurasi bot dari %s: %v", BotConfigFile, err)
+	}
+
+	// 3. Initialize Bot
+	bot, err := tgbotapi.NewBotAPI(config.BotToken)
+	if err != nil {
+		log.Panicf("FATAL: Gagal inisialisasi Bot API: %v", err)
+	}
+
+	bot.Debug = false
+	log.Printf("Authorized on account %s. AdminID: %d. Mode: %s.", bot.Self.UserName, config.AdminID, config.Mode)
+	if notice := backup.BotRollbackNotice(SnapshotDir); notice != "" {
+		bot.Send(tgbotapi.NewMessage(config.AdminID, notice))
+	}
+
+	// 4. Session Manager
+	ttl := DefaultSessionTTL
+	if config.SessionTTL > 0 {
+		ttl = time.Duration(config.SessionTTL) * time.Minute
+	}
+	sessionFile := ""
+	if config.PersistSessions {
+		sessionFile = SessionFile
+	}
+	sessions = session.NewManager(ttl, sessionFile)
+	sessions.StartJanitor(time.Minute, func(userID int64, s session.Session) {
+		if s.State == "" || s.ChatID == 0 {
+			return
+		}
+		sendMessage(bot, s.ChatID, "⌛ Sesi berakhir karena tidak ada aktivitas. Ketik /start untuk mulai lagi.")
+	})
+	botConfig.Store(&config)
+	setupUI(bot)
+	registerFlows(bot)
+	go ui.RunBackupSchedule(config.Schedule, config.AdminID)
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+	updates := bot.GetUpdatesChan(u)
+
+	// 5. Main Loop
+	for update := range updates {
+		if update.Message != nil {
+			// Menggunakan goroutine agar bot tidak terblokir saat memproses request
+			go handleMessage(bot, update.Message, currentConfig())
+		} else if update.CallbackQuery != nil {
+			go handleCallback(bot, update.CallbackQuery, currentConfig())
+		}
+	}
+}
+
+// setupAPIConfig membaca file API Key dan Port, lalu mengupdate variabel global ApiKey dan ApiUrl
+func setupAPIConfig() {
+	// Load API Key (API memakai key default yang sama jika file tidak ada)
+	key, err := config.LoadApiKey()
+	if err != nil {
+		log.Printf("WARNING: Gagal membaca API Key dari %s: %v. Menggunakan default.", ApiKeyFile, err)
+	} else {
+		log.Printf("INFO: API Key loaded successfully.")
+	}
+	ApiKey = key
+
+	// Load API Port
+	port, err := config.LoadApiPort()
+	if err != nil {
+		log.Printf("WARNING: Gagal membaca API Port dari %s: %v. Menggunakan default: %d", ApiPortFile, err, port)
+	}
+	ApiUrl = config.ApiURL(port)
+	log.Printf("INFO: API URL set to %s", ApiUrl)
+
+	api = apiclient.New(ApiUrl, ApiKey)
+}
+
+// ==========================================
+// Telegram Event Handlers
+// ==========================================
+
+func handleMessage(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, config *BotConfig) {
+	// Cek akses sebelum melakukan operasi apapun
+	if !isAllowed(config, msg.From.ID) {
+		replyError(bot, msg.Chat.ID, "⛔ Akses Ditolak. Bot ini Private.")
+		return
+	}
+	if isBanned(config, msg.From.ID) {
+		replyError(bot, msg.Chat.ID, "⛔ Akses Ditolak. Akun Telegram Anda diblokir.")
+		return
+	}
+
+	// Handle Input Alur Percakapan (termasuk file restore)
+	if flows.HandleMessage(msg) {
+		return
+	}
+
+	// File CSV/JSON dari Admin diperlakukan sebagai import user
+	if msg.Document != nil && config.Can(msg.From.ID, PermUsers) {
+		if format := bulk.ImportFormat(msg.Document.FileName); format != "" {
+			ui.PreviewImport(msg, format)
+			return
+		}
+	}
+
+	// Handle Commands
+	if msg.IsCommand() {
+		switch msg.Command() {
+		case "start":
+			showMainMenu(bot, msg.Chat.ID, config)
+		case "akun":
+			ui.ShowMyAccounts(msg.Chat.ID, msg.From.ID)
+		case "ban", "unban", "banned":
+			if config.Can(msg.From.ID, PermUsers) {
+				handleBanCommand(bot, msg, config)
+			}
+		case "addadmin", "deladmin", "admins", "adminlog":
+			if msg.From.ID == config.AdminID {
+				handleAdminCommand(bot, msg, config)
+			}
+		case "audit":
+			if config.Can(msg.From.ID, PermUsers) {
+				ui.ShowAuditLog(msg.Chat.ID, msg.CommandArguments())
+			}
+		case "bulkcreate", "bulkextend", "bulkdelete", "bulklock", "bulkunlock", "compensate":
+			if config.Can(msg.From.ID, PermUsers) {
+				ui.HandleBulkCommand(msg)
+			}
+		case "export":
+			if config.Can(msg.From.ID, PermUsers) {
+				ui.ExportUsers(msg)
+			}
+		default:
+			replyError(bot, msg.Chat.ID, "Perintah tidak dikenal. Ketik /start untuk menu.")
+		}
+	}
+}
+
+func handleCallback(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery, config *BotConfig) {
+	chatID := query.Message.Chat.ID
+	userID := query.From.ID
+
+	// Khusus untuk toggle_mode, hanya Admin yang boleh
+	if query.Data == "toggle_mode" && !config.Can(userID, PermMode) {
+		bot.Request(tgbotapi.NewCallback(query.ID, "Akses Ditolak"))
+		return
+	}
+
+	if isBanned(config, userID) {
+		bot.Request(tgbotapi.NewCallback(query.ID, "Akun Telegram Anda diblokir"))
+		return
+	}
+
+	// Tombol Kembali/Batal milik alur percakapan
+	if flows.HandleCallback(query) {
+		return
+	}
+
+	// Hapus state/temp data sebelum menjalankan aksi callback baru (kecuali
+	// pagination dan konfirmasi perintah massal/import/restore yang membaca sesi)
+	if !strings.HasPrefix(query.Data, "page_") && query.Data != botui.CallbackBulk && query.Data != botui.CallbackImport && query.Data != botui.CallbackRestore {
+		sessions.Reset(userID)
+	}
+
+	switch {
+	// --- Menu Navigation ---
+	case query.Data == "menu_create":
+		if reason, ok := checkCreateAllowed(bot, config, userID); !ok {
+			replyError(bot, chatID, reason)
+			break
+		}
+		startCreateUser(bot, chatID, userID)
+	case query.Data == "menu_delete":
+		showUserSelection(bot, chatID, userID, userSelection{Action: "delete", Page: 1}, config)
+	case query.Data == "menu_renew":
+		showUserSelection(bot, chatID, userID, userSelection{Action: "renew", Page: 1}, config)
+	case query.Data == "menu_list":
+		listUsers(bot, chatID, userID, config)
+	case query.Data == "menu_info":
+		if config.Can(userID, PermUsers) {
+			systemInfo(bot, chatID, config)
+		}
+	case query.Data == "menu_backup_restore":
+		if config.Can(userID, PermBackup) {
+			showBackupRestoreMenu(bot, chatID)
+		}
+	case query.Data == "menu_backup_action":
+		if config.Can(userID, PermBackup) {
+			logAdminAction(userID, "backup", "")
+			ui.SendBackup(chatID)
+		}
+	case query.Data == "menu_restore_action":
+		if config.Can(userID, PermBackup) {
+			startRestore(bot, chatID, userID)
+		}
+	case query.Data == botui.CallbackRestore:
+		if config.Can(userID, PermBackup) {
+			ui.ConfirmRestore(chatID, userID)
+		}
+	case query.Data == "menu_my_accounts":
+		ui.ShowMyAccounts(chatID, userID)
+	case query.Data == "cancel":
+		cancelOperation(bot, chatID, userID, config)
+	case query.Data == botui.CallbackBulk:
+		if config.Can(userID, PermUsers) {
+			ui.ConfirmBulk(chatID, userID)
+		}
+	case query.Data == botui.CallbackImport:
+		if config.Can(userID, PermUsers) {
+			ui.ConfirmImport(chatID, userID)
+		}
+
+	// --- Pagination & Pencarian ---
+	case strings.HasPrefix(query.Data, "page_"):
+		handlePagination(bot, chatID, userID, query.Data, config)
+	case strings.HasPrefix(query.Data, "search_"):
+		startUserSearch(bot, chatID, userID, query.Data)
+
+	// --- Action Selection & Confirmation ---
+	case strings.HasPrefix(query.Data, "select_renew:"):
+		startRenewUser(bot, chatID, userID, query.Data, config)
+	case strings.HasPrefix(query.Data, "select_delete:"):
+		confirmDeleteUser(bot, chatID, userID, query.Data, config)
+	case strings.HasPrefix(query.Data, "confirm_delete:"):
+		username := strings.TrimPrefix(query.Data, "confirm_delete:")
+		if !canManageAccount(config, userID, username) {
+			replyError(bot, chatID, "❌ Akun tidak ditemukan.")
+			break
+		}
+		if config.IsAdmin(userID) {
+			logAdminAction(userID, "delete", username)
+		}
+		deleteUser(bot, chatID, userID, username, config)
+
+	// --- Akun Saya ---
+	case strings.HasPrefix(query.Data, "acc:"):
+		ui.ShowAccountDetail(chatID, userID, strings.TrimPrefix(query.Data, "acc:"))
+	case strings.HasPrefix(query.Data, "acc_info:"):
+		ui.ShowConnectionInfo(chatID, userID, strings.TrimPrefix(query.Data, "acc_info:"))
+	case strings.HasPrefix(query.Data, "acc_rotate:"):
+		ui.ConfirmRotatePassword(chatID, userID, strings.TrimPrefix(query.Data, "acc_rotate:"))
+	case strings.HasPrefix(query.Data, "acc_rotate_ok:"):
+		ui.RotatePassword(chatID, userID, strings.TrimPrefix(query.Data, "acc_rotate_ok:"))
+
+	// --- Admin Actions ---
+	case query.Data == "toggle_mode":
+		logAdminAction(userID, "toggle_mode", "")
+		toggleMode(bot, chatID, userID)
+	}
+
+	// Always respond to callback queries to remove the 'loading' state
+	bot.Request(tgbotapi.NewCallback(query.ID, ""))
+}
+
+// ==========================================
+// Alur Percakapan
+// ==========================================
+
+// registerFlows mendaftarkan semua alur input bertahap bot. Alur berjalan
+// lama, jadi konfigurasi dibaca ulang lewat currentConfig setiap langkah.
+func registerFlows(bot *tgbotapi.BotAPI) {
+	flows = flow.New(bot, sessions)
+	flows.DeleteInput = true
+	flows.Send = func(msg tgbotapi.MessageConfig) {
+		deleteLastMessage(bot, msg.ChatID)
+		sendAndTrack(bot, msg)
+	}
+	backToMenu := func(ctx *flow.Context) {
+		showMainMenu(bot, ctx.ChatID, currentConfig())
+	}
+	days := flow.Step{
+		Key: "days",
+		PromptFunc: func(ctx *flow.Context) string {
+			return fmt.Sprintf("⏳ Masukkan Durasi (hari) untuk akun ini (1-%d):", maxDaysFor(currentConfig(), ctx.UserID))
+		},
+		Validate: func(input string, ctx *flow.Context) (string, error) {
+			return flow.Number(1, maxDaysFor(currentConfig(), ctx.UserID), "Durasi")(input, ctx)
+		},
+	}
+
+	flows.Register(&flow.Flow{
+		Name: "create",
+		Steps: []flow.Step{
+			{Key: "username", Prompt: "👤 Masukkan Password untuk akun baru (3-20 karakter):", Validate: flow.Password},
+			days,
+		},
+		Finish: func(ctx *flow.Context) error {
+			config := currentConfig()
+			// Cek ulang batasan, kondisi bisa berubah sejak menu dibuka
+			if reason, ok := checkCreateAllowed(bot, config, ctx.UserID); !ok {
+				replyError(bot, ctx.ChatID, reason)
+				showMainMenu(bot, ctx.ChatID, config)
+				return nil
+			}
+			d, _ := strconv.Atoi(ctx.Data["days"])
+			// Panggil createUser di goroutine untuk tidak memblokir bot
+			go createUser(bot, ctx.ChatID, ctx.UserID, ctx.Data["username"], d, config)
+			return nil
+		},
+		OnCancel: backToMenu,
+	})
+
+	flows.Register(&flow.Flow{
+		Name:  "renew",
+		Steps: []flow.Step{days},
+		Finish: func(ctx *flow.Context) error {
+			config := currentConfig()
+			d, _ := strconv.Atoi(ctx.Data["days"])
+			if config.IsAdmin(ctx.UserID) {
+				logAdminAction(ctx.UserID, "renew", fmt.Sprintf("%s +%d hari", ctx.Data["username"], d))
+			}
+			// Panggil renewUser di goroutine
+			go renewUser(bot, ctx.ChatID, ctx.UserID, ctx.Data["username"], d, config)
+			return nil
+		},
+		OnCancel: backToMenu,
+	})
+
+	flows.Register(&flow.Flow{
+		Name: "search",
+		Steps: []flow.Step{
+			{Key: "q", Prompt: "🔍 Masukkan kata kunci password yang dicari (maks 20 karakter):", Validate: flow.Keyword},
+		},
+		Finish: func(ctx *flow.Context) error {
+			sel := userSelection{Action: ctx.Data["action"], Status: ctx.Data["status"], Page: 1, Search: ctx.Data["q"]}
+			showUserSelection(bot, ctx.ChatID, ctx.UserID, sel, currentConfig())
+			return nil
+		},
+		OnCancel: backToMenu,
+	})
+
+	flows.Register(&flow.Flow{
+		Name: "restore",
+		Steps: []flow.Step{
+			{Key: "file", Document: true, Prompt: "⬆️ *Restore Data*\n\nSilakan kirim file backup (.zvbak) Anda sekarang.\n\n⚠️ PERINGATAN: Data saat ini akan ditimpa!"},
+		},
+		Finish: func(ctx *flow.Context) error {
+			// Izin dicek ulang, bisa dicabut saat alur berjalan
+			config := currentConfig()
+			if !config.Can(ctx.UserID, PermBackup) {
+				return nil
+			}
+			ui.PreviewRestore(ctx.Message)
+			return nil
+		},
+		OnCancel: backToMenu,
+	})
+}
+
+func startCreateUser(bot *tgbotapi.BotAPI, chatID int64, userID int64) {
+	flows.Start("create", userID, chatID, nil)
+}
+
+func startRenewUser(bot *tgbotapi.BotAPI, chatID int64, userID int64, data string, config *BotConfig) {
+	username := strings.TrimPrefix(data, "select_renew:")
+	if !canManageAccount(config, userID, username) {
+		replyError(bot, chatID, "❌ Akun tidak ditemukan.")
+		return
+	}
+	flows.Start("renew", userID, chatID, map[string]string{"username": username})
+}
+
+func startRestore(bot *tgbotapi.BotAPI, chatID int64, userID int64) {
+	flows.Start("restore", userID, chatID, nil)
+}
+
+// ==========================================
+// Feature Implementation
+// ==========================================
+
+func createUser(bot *tgbotapi.BotAPI, chatID int64, ownerID int64, username string, days int, config *BotConfig) {
+	acc, err := api.As(ownerID).CreateUser(apiclient.CreateUserRequest{
+		Password: username,
+		Days:     days,
+		OwnerID:  ownerID, // Pembuat akun dicatat sebagai pemilik
+	})
+	if err != nil {
+		ui.APIError(chatID, "membuat akun", err)
+		showMainMenu(bot, chatID, config)
+		return
+	}
+
+	limitMutex.Lock()
+	lastCreateAt[ownerID] = time.Now()
+	limitMutex.Unlock()
+	sendAccountInfo(bot, chatID, acc, config)
+}
+
+func renewUser(bot *tgbotapi.BotAPI, chatID int64, userID int64, username string, days int, config *BotConfig) {
+	// API memeriksa kepemilikan akun berdasarkan user pelaku
+	acc, err := api.As(userID).RenewUser(apiclient.RenewUserRequest{
+		Password: username,
+		Days:     days,
+		OwnerID:  ownerFilter(config, userID), // 0 untuk Admin
+	})
+	if err != nil {
+		ui.APIError(chatID, "memperpanjang akun", err)
+		showMainMenu(bot, chatID, config)
+		return
+	}
+	sendAccountInfo(bot, chatID, acc, config)
+}
+
+func deleteUser(bot *tgbotapi.BotAPI, chatID int64, userID int64, username string, config *BotConfig) {
+	// API memeriksa kepemilikan akun berdasarkan user pelaku
+	err := api.As(userID).DeleteUser(apiclient.DeleteUserRequest{
+		Password: username,
+		OwnerID:  ownerFilter(config, userID), // 0 untuk Admin
+	})
+	if err != nil {
+		ui.APIError(chatID, "menghapus akun", err)
+		showMainMenu(bot, chatID, config)
+		return
+	}
+
+	deleteLastMessage(bot, chatID)
+	sendMessage(bot, chatID, fmt.Sprintf("✅ Password `%s` berhasil dihapus.", username))
+	showMainMenu(bot, chatID, config)
+}
+
+// ==========================================
+// Admin & Izin
+// ==========================================
+
+// parsePermissions mengubah "users,backup" atau "all" menjadi daftar izin yang valid
+func parsePermissions(raw string) ([]string, error) {
+	return config.ParsePermissions(raw)
+}
+
+// logAdminAction menambahkan satu entri ke log audit admin
+func logAdminAction(adminID int64, action, detail string) {
+	if err := storage.LogAdminAction(AdminLogFile, adminID, action, detail); err != nil {
+		log.Printf("ERROR: Gagal menulis log admin: %v", err)
+	}
+}
+
+// readAdminLog mengembalikan n entri terakhir, terbaru lebih dulu
+func readAdminLog(n int) ([]storage.AdminAction, error) {
+	return storage.ReadAdminLog(AdminLogFile, n)
+}
+
+// handleAdminCommand menangani /addadmin, /deladmin, /admins, dan /adminlog (khusus Owner)
+func handleAdminCommand(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, config *BotConfig) {
+	chatID := msg.Chat.ID
+	args := strings.Fields(msg.CommandArguments())
+
+	switch msg.Command() {
+	case "admins":
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("👮 *DAFTAR ADMIN*\n\n╠═ Owner `%d` (semua izin)\n", config.AdminID))
+		for _, a := range config.Admins {
+			sb.WriteString(fmt.Sprintf("╠═ `%d` %s: %s\n", a.ID, a.Name, strings.Join(a.Permissions, ", ")))
+		}
+		sb.WriteString("\nIzin tersedia: " + strings.Join(allPermissions, ", "))
+		sendMessage(bot, chatID, sb.String())
+
+	case "adminlog":
+		n := 20
+		if len(args) > 0 {
+			if v, err := strconv.Atoi(args[0]); err == nil && v > 0 && v <= 100 {
+				n = v
+			}
+		}
+		entries, err := readAdminLog(n)
+		if err != nil {
+			replyError(bot, chatID, "❌ Gagal membaca log admin: "+err.Error())
+			return
+		}
+		if len(entries) == 0 {
+			sendMessage(bot, chatID, "Log admin masih kosong.")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("📜 LOG ADMIN\n\n")
+		for _, e := range entries {
+			sb.WriteString(fmt.Sprintf("%s | %d | %s %s\n", e.Time, e.AdminID, e.Action, e.Detail))
+		}
+		// Dikirim tanpa Markdown karena detail bisa berisi input user
+		bot.Send(tgbotapi.NewMessage(chatID, sb.String()))
+
+	case "addadmin":
+		if len(args) < 2 {
+			replyError(bot, chatID, "Format: `/addadmin <user_id> <izin1,izin2|all> [nama]`")
+			return
+		}
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || id == 0 || id == config.AdminID {
+			replyError(bot, chatID, "User ID tidak valid.")
+			return
+		}
+		perms, err := parsePermissions(args[1])
+		if err != nil {
+			replyError(bot, chatID, err.Error())
+			return
+		}
+
+		admin := Admin{ID: id, Name: strings.Join(args[2:], " "), Permissions: perms}
+		_, err = updateConfig(func(cfg *BotConfig) error {
+			for i, a := range cfg.Admins {
+				if a.ID == id {
+					cfg.Admins[i] = admin
+					return nil
+				}
+			}
+			cfg.Admins = append(cfg.Admins, admin)
+			return nil
+		})
+		if err != nil {
+			replyError(bot, chatID, err.Error())
+			return
+		}
+		logAdminAction(msg.From.ID, "admin_add", fmt.Sprintf("%d %s", id, strings.Join(perms, ",")))
+		sendMessage(bot, chatID, fmt.Sprintf("✅ Admin `%d` disimpan dengan izin: %s", id, strings.Join(perms, ", ")))
+
+	case "deladmin":
+		if len(args) < 1 {
+			replyError(bot, chatID, "Format: `/deladmin <user_id>`")
+			return
+		}
+		id, _ := strconv.ParseInt(args[0], 10, 64)
+
+		_, err := updateConfig(func(cfg *BotConfig) error {
+			var admins []Admin
+			for _, a := range cfg.Admins {
+				if a.ID != id {
+					admins = append(admins, a)
+				}
+			}
+			if len(admins) == len(cfg.Admins) {
+				return fmt.Errorf("Admin tidak ditemukan.")
+			}
+			cfg.Admins = admins
+			return nil
+		})
+		if err != nil {
+			replyError(bot, chatID, err.Error())
+			return
+		}
+		resetState(id)
+		logAdminAction(msg.From.ID, "admin_remove", strconv.FormatInt(id, 10))
+		sendMessage(bot, chatID, fmt.Sprintf("✅ Admin `%d` dihapus.", id))
+	}
+}
+
+// ==========================================
+// Kepemilikan Akun
+// ==========================================
+
+// ownerFilter mengembalikan owner_id untuk membatasi akses akun.
+// Admin dengan izin users mendapat 0 (semua akun), user lain hanya akun miliknya sendiri.
+func ownerFilter(config *BotConfig, userID int64) int64 {
+	if config.Can(userID, PermUsers) {
+		return 0
+	}
+	return userID
+}
+
+// canManageAccount memeriksa apakah user boleh memperpanjang/menghapus akun tertentu
+func canManageAccount(config *BotConfig, userID int64, password string) bool {
+	if config.Can(userID, PermUsers) {
+		return true
+	}
+	_, ok := ui.FindOwnedAccount(userID, password)
+	return ok
+}
+
+// ==========================================
+// Batasan & Anti-Abuse
+// ==========================================
+
+// maxDaysFor mengembalikan durasi maksimal yang boleh dipilih user
+func maxDaysFor(config *BotConfig, userID int64) int {
+	if config.IsAdmin(userID) || config.MaxDays <= 0 || config.MaxDays > 9999 {
+		return 9999
+	}
+	return config.MaxDays
+}
+
+// checkCreateAllowed memeriksa semua batasan pembuatan akun untuk user non-admin.
+// Mengembalikan alasan penolakan jika tidak diizinkan.
+func checkCreateAllowed(bot *tgbotapi.BotAPI, config *BotConfig, userID int64) (string, bool) {
+	if config.IsAdmin(userID) {
+		return "", true
+	}
+
+	if config.RequiredChannel != "" && !isChannelMember(bot, config.RequiredChannel, userID) {
+		return fmt.Sprintf("📢 Silakan join %s terlebih dahulu, lalu coba lagi.", config.RequiredChannel), false
+	}
+
+	if config.CreateCooldown > 0 {
+		limitMutex.Lock()
+		last, exists := lastCreateAt[userID]
+		limitMutex.Unlock()
+		if wait := time.Until(last.Add(time.Duration(config.CreateCooldown) * time.Minute)); exists && wait > 0 {
+			return fmt.Sprintf("⏳ Tunggu %d menit lagi sebelum membuat akun baru.", int(wait.Minutes())+1), false
+		}
+	}
+
+	if config.MaxAccounts > 0 {
+		users, err := api.ListUsers(apiclient.ListOptions{OwnerID: userID})
+		if err != nil {
+			log.Printf("ERROR: Gagal menghitung akun milik %d: %v", userID, err)
+			return "❌ Gagal memeriksa jumlah akun Anda. Coba lagi nanti.", false
+		}
+		if len(users) >= config.MaxAccounts {
+			return fmt.Sprintf("❌ Batas maksimal %d akun per user tercapai.", config.MaxAccounts), false
+		}
+	}
+
+	return "", true
+}
+
+// isChannelMember memeriksa keanggotaan user di channel. Bot harus menjadi admin channel.
+func isChannelMember(bot *tgbotapi.BotAPI, channel string, userID int64) bool {
+	ok, err := telegram.IsChannelMember(bot, channel, userID)
+	if err != nil {
+		log.Printf("WARNING: Gagal cek keanggotaan %d di %s: %v", userID, channel, err)
+	}
+	return ok
+}
+
+func isBanned(config *BotConfig, userID int64) bool {
+	for _, id := range config.BannedUsers {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// handleBanCommand menangani /ban <id>, /unban <id>, dan /banned (khusus Admin)
+func handleBanCommand(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, config *BotConfig) {
+	chatID := msg.Chat.ID
+
+	if msg.Command() == "banned" {
+		if len(config.BannedUsers) == 0 {
+			sendMessage(bot, chatID, "✅ Daftar blokir kosong.")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("⛔ *DAFTAR USER DIBLOKIR*\n\n")
+		for _, id := range config.BannedUsers {
+			sb.WriteString(fmt.Sprintf("╠═ `%d`\n", id))
+		}
+		sendMessage(bot, chatID, sb.String())
+		return
+	}
+
+	target, err := strconv.ParseInt(strings.TrimSpace(msg.CommandArguments()), 10, 64)
+	if err != nil || target == 0 {
+		replyError(bot, chatID, fmt.Sprintf("Format: `/%s <user_id>`", msg.Command()))
+		return
+	}
+	if config.IsAdmin(target) {
+		replyError(bot, chatID, "Admin tidak bisa diblokir.")
+		return
+	}
+
+	_, err = updateConfig(func(cfg *BotConfig) error {
+		var banned []int64
+		for _, id := range cfg.BannedUsers {
+			if id != target {
+				banned = append(banned, id)
+			}
+		}
+		if msg.Command() == "ban" {
+			banned = append(banned, target)
+		}
+		cfg.BannedUsers = banned
+		return nil
+	})
+	if err != nil {
+		replyError(bot, chatID, err.Error())
+		return
+	}
+
+	logAdminAction(msg.From.ID, msg.Command(), strconv.FormatInt(target, 10))
+	if msg.Command() == "ban" {
+		resetState(target)
+		sendMessage(bot, chatID, fmt.Sprintf("⛔ User `%d` diblokir.", target))
+	} else {
+		sendMessage(bot, chatID, fmt.Sprintf("✅ User `%d` dibuka blokirnya.", target))
+	}
+}
+
+// ==========================================
+// UI & Helpers
+// ==========================================
+
+// Fungsi showMainMenu, getMainMenuKeyboard, sendAccountInfo, dll.
+
+func showMainMenu(bot *tgbotapi.BotAPI, chatID int64, config *BotConfig) {
+	ipInfo, _ := getIpInfo()
+	domain := config.Domain
+	if domain == "" {
+		// Coba baca lagi dari file domain
+		if domainBytes, err := os.ReadFile(DomainFile); err == nil {
+			domain = strings.TrimSpace(string(domainBytes))
+		}
+		if domain == "" {
+			domain = "_(Belum Dikonfigurasi)_"
+		}
+	}
+
+	// Menggunakan format Markdown yang lebih kaya
+	msgText := fmt.Sprintf("✨ *SELAMAT DATANG DI ZIVPN UDP BOT* ✨\n\n"+
+		"╔═══════ 🇮🇩 *INFO SERVER* ═══════\n"+
+		"║\n"+
+		"╠═ 🌐 *Domain* : `%s`\n"+
+		"╠═ 🏙️ *Kota* : `%s`\n"+
+		"╠═ 📡 *ISP* : `%s`\n"+
+		"║\n"+
+		"╚═════════════════════════\n\n"+
+		"👇 *Pilih Menu Transaksi Anda* 👇",
+		domain, ipInfo.City, ipInfo.Isp)
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = getMainMenuKeyboard(config, chatID)
+	sendAndTrack(bot, msg)
+}
+
+func sendAccountInfo(bot *tgbotapi.BotAPI, chatID int64, acc apiclient.Account, config *BotConfig) {
+	ipInfo, _ := getIpInfo()
+	domain := config.Domain
+	if domain == "" {
+		// Coba baca lagi dari file domain
+		if domainBytes, err := os.ReadFile(DomainFile); err == nil {
+			domain = strings.TrimSpace(string(domainBytes))
+		}
+		if domain == "" {
+			domain = "_(Belum Dikonfigurasi)_"
+		}
+	}
+	
+	password, expired := acc.Password, acc.Expired
+	
+	// Menggunakan format yang lebih visual dengan emoji dan penekanan (Bold)
+	msg := fmt.Sprintf("🔑 *DETAIL AKUN ZIVPN UDP*\n\n"+
+		"╔═══════ 🔰 *INFORMASI AKUN* ═══════\n"+
+		"║\n"+
+		"╠═ 🔓 *Password* : `%s`\n"+
+		"╠═ 📅 *Expired* : `%s`\n"+
+		"║\n"+
+		"╚════════════════════════════\n\n"+
+		"╔═══════ 🌐 *INFORMASI SERVER* ═══════\n"+
+		"║\n"+
+		"╠═ 🌐 *Domain* : `%s`\n"+
+		"╠═ 🏙️ *Kota* : `%s`\n"+
+		"╠═ 📡 *ISP* : `%s`\n"+
+		"╠═ 📍 *IP ISP* : `%s`\n"+
+		"║\n"+
+		"╚════════════════════════════\n\n"+
+		"🚀 *Akun siap digunakan!* Harap jaga kerahasiaan password Anda.",
+		password,
+		expired,
+		domain,
+		ipInfo.City,
+		ipInfo.Isp,
+		ipInfo.Query,
+	)
+
+	reply := tgbotapi.NewMessage(chatID, msg)
+	reply.ParseMode = "Markdown"
+	deleteLastMessage(bot, chatID)
+	bot.Send(reply)
+	showMainMenu(bot, chatID, config)
+}
+
+// setupUI menyiapkan ui agar memakai pesan menu dan format error bot ini
+func setupUI(bot *tgbotapi.BotAPI) {
+	ui = botui.New(bot, api, sessions, "zivpn-bot")
+	ui.Show = func(msg tgbotapi.MessageConfig) {
+		deleteLastMessage(bot, msg.ChatID)
+		sendAndTrack(bot, msg)
+	}
+	ui.DeleteLast = func(chatID int64) { deleteLastMessage(bot, chatID) }
+	ui.Error = func(chatID int64, text string) { replyError(bot, chatID, "❌ "+text) }
+	ui.AccountInfo = func(chatID int64, acc apiclient.Account) { sendAccountInfo(bot, chatID, acc, currentConfig()) }
+	ui.NewConfig = func() interface{} { return &BotConfig{} }
+}
+
+// sendMessage mengirim pesan dan mengembalikan ID pesan
+func sendMessage(bot *tgbotapi.BotAPI, chatID int64, text string) int {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	if sentMsg, err := bot.Send(msg); err == nil {
+		return sentMsg.MessageID
+	}
+	return 0
+}
+
+// replyError mengirim pesan error
+func replyError(bot *tgbotapi.BotAPI, chatID int64, text string) {
+	sendMessage(bot, chatID, "🚨 ERROR: "+text)
+}
+
+// sendAndTrack mengirim pesan dan mencatat ID-nya untuk dihapus nanti
+func sendAndTrack(bot *tgbotapi.BotAPI, msg tgbotapi.Chattable) {
+	if sentMsg, err := bot.Send(msg); err == nil {
+		sessions.SetLastMessage(sentMsg.Chat.ID, sentMsg.MessageID)
+	}
+}
+
+// deleteLastMessage menghapus pesan terakhir yang dikirim bot
+func deleteLastMessage(bot *tgbotapi.BotAPI, chatID int64) {
+	if msgID, exists := sessions.TakeLastMessage(chatID); exists {
+		deleteMessage(bot, chatID, msgID)
+	}
+}
+
+// deleteMessage menghapus pesan spesifik
+func deleteMessage(bot *tgbotapi.BotAPI, chatID int64, messageID int) {
+	if err := telegram.DeleteMessage(bot, chatID, messageID); err != nil {
+		// Log error jika gagal menghapus (misal, pesan terlalu lama)
+		log.Printf("WARNING: Gagal menghapus pesan ID %d di chat %d: %v", messageID, chatID, err)
+	}
+}
+
+// resetState menghapus state dan data sementara pengguna
+func resetState(userID int64) {
+	sessions.Reset(userID)
+}
+
+// ==========================================
+// Validation & Config Helpers
+// ==========================================
+
+func isAllowed(config *BotConfig, userID int64) bool {
+	// Mode "public" diizinkan untuk semua, mode "private" hanya untuk Admin
+	return config.Mode == "public" || config.IsAdmin(userID)
+}
+
+func saveConfig(cfg *BotConfig) error {
+	return storage.WriteJSON(BotConfigFile, cfg, 0644)
+}
+
+// currentConfig mengembalikan konfigurasi aktif. Jangan diubah langsung,
+// gunakan updateConfig.
+func currentConfig() *BotConfig {
+	return botConfig.Load()
+}
+
+// updateConfig menjalankan change pada salinan konfigurasi aktif, menyimpannya,
+// lalu menjadikannya konfigurasi aktif. Jika change atau penyimpanan gagal,
+// konfigurasi lama tetap dipakai.
+func updateConfig(change func(cfg *BotConfig) error) (*BotConfig, error) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	next := *currentConfig()
+	next.Admins = append([]Admin(nil), next.Admins...)
+	next.BannedUsers = append([]int64(nil), next.BannedUsers...)
+	if err := change(&next); err != nil {
+		return nil, err
+	}
+	if err := saveConfig(&next); err != nil {
+		return nil, fmt.Errorf("❌ Gagal menyimpan konfigurasi: %v", err)
+	}
+	botConfig.Store(&next)
+	return &next, nil
+}
+
+func loadConfig() (BotConfig, error) {
+	var cfg BotConfig
+	if err := storage.ReadJSON(BotConfigFile, &cfg); err != nil {
+		return cfg, err
+	}
+
+	// Pastikan Domain dibaca, baik dari config atau file domain
+	if cfg.Domain == "" {
+		cfg.Domain = config.LoadDomain()
+	}
+
+	return cfg, nil
+}
+
+// ==========================================
+// API Client
+// ==========================================
+
+func getIpInfo() (IpInfo, error) {
+	// Timeout untuk permintaan eksternal
+	client := http.Client{Timeout: 5 * time.Second} 
+	resp, err := client.Get("http://ip-api.com/json/")
+	if err != nil {
+		log.Printf("ERROR: Gagal fetching IP info: %v", err)
+		// Return IpInfo kosong dengan error
+		return IpInfo{City: "N/A", Isp: "N/A", Query: "N/A"}, err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return IpInfo{City: "N/A", Isp: "N/A", Query: "N/A"}, fmt.Errorf("status code ip-api: %d", resp.StatusCode)
+	}
+
+	var info IpInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return IpInfo{City: "N/A", Isp: "N/A", Query: "N/A"}, err
+	}
+	return info, nil
+}
+
+// ==========================================
+// Menu & Pilihan Akun
+// ==========================================
+
+// UsersPerPage adalah jumlah akun per halaman pada pilihan akun
+const UsersPerPage = 10
+
+// ExpiringSoonDays adalah batas hari untuk filter "Segera Habis"
+const ExpiringSoonDays = 7
+
+// getMainMenuKeyboard menampilkan menu user, ditambah menu admin sesuai izin
+func getMainMenuKeyboard(config *BotConfig, userID int64) tgbotapi.InlineKeyboardMarkup {
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ Buat Akun", "menu_create"),
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Perpanjang", "menu_renew"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑️ Hapus Akun", "menu_delete"),
+			tgbotapi.NewInlineKeyboardButtonData("👤 Akun Saya", "menu_my_accounts"),
+		),
+	}
+
+	if config.Can(userID, PermUsers) {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📋 Daftar User", "menu_list"),
+			tgbotapi.NewInlineKeyboardButtonData("📊 Info Server", "menu_info"),
+		))
+	}
+	if config.Can(userID, PermBackup) {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💾 Backup & Restore", "menu_backup_restore"),
+		))
+	}
+	if config.Can(userID, PermMode) {
+		label := "🔒 Mode: Private (ubah ke Public)"
+		if config.Mode == "public" {
+			label = "🔓 Mode: Public (ubah ke Private)"
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, "toggle_mode"),
+		))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// userSelection adalah keadaan daftar akun yang sedang dilihat user
+type userSelection struct {
+	Action string // "list", "delete" atau "renew"
+	Status string // "", "active", "locked", "expired" atau "expiring"
+	Page   int
+	Search string // bagian dari password, "" = semua
+}
+
+// pageData membentuk callback "page_<action>:<status>:<halaman>:<kata kunci>"
+func (s userSelection) pageData(page int) string {
+	return fmt.Sprintf("page_%s:%s:%d:%s", s.Action, s.Status, page, s.Search)
+}
+
+// parseUserSelection membaca callback dari pageData
+func parseUserSelection(data string) (userSelection, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(data, "page_"), ":", 4)
+	if len(parts) != 4 {
+		return userSelection{}, false
+	}
+	page, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return userSelection{}, false
+	}
+	sel := userSelection{Action: parts[0], Status: parts[1], Page: page, Search: parts[3]}
+	return sel, sel.valid()
+}
+
+// valid memeriksa aksi dan filter, nilainya berasal dari callback
+func (s userSelection) valid() bool {
+	switch s.Action {
+	case "list", "delete", "renew":
+	default:
+		return false
+	}
+	for _, f := range userFilters {
+		if f.Status == s.Status {
+			return true
+		}
+	}
+	return false
+}
+
+// userFilters adalah tombol filter status pada daftar akun
+var userFilters = []struct{ Status, Label string }{
+	{"", "Semua"},
+	{"active", "Aktif"},
+	{"locked", "Terkunci"},
+	{"expired", "Expired"},
+	{"expiring", "Segera Habis"},
+}
+
+// showUserSelection menampilkan akun yang boleh dikelola user, per halaman.
+// Filter, pencarian dan pembagian halaman dikerjakan oleh API.
+func showUserSelection(bot *tgbotapi.BotAPI, chatID int64, userID int64, sel userSelection, config *BotConfig) {
+	if sel.Action == "list" && !config.Can(userID, PermUsers) {
+		return
+	}
+	if sel.Page < 1 {
+		sel.Page = 1
+	}
+
+	opts := apiclient.ListOptions{
+		OwnerID: ownerFilter(config, userID),
+		Search:  sel.Search,
+		Sort:    "password",
+		Page:    sel.Page,
+		Limit:   UsersPerPage,
+	}
+	if sel.Status == "expiring" {
+		opts.ExpiringDays = ExpiringSoonDays
+		opts.Sort = "expired"
+	} else {
+		opts.Status = sel.Status
+	}
+
+	result, err := api.ListUsersPage(opts)
+	if err == nil && len(result.Users) == 0 && result.Total > 0 {
+		// Halaman sudah tidak ada (misalnya akun baru dihapus), ambil halaman terakhir
+		sel.Page = result.Pages
+		opts.Page = result.Pages
+		result, err = api.ListUsersPage(opts)
+	}
+	if err != nil {
+		ui.APIError(chatID, "mengambil daftar akun", err)
+		return
+	}
+
+	var text strings.Builder
+	switch sel.Action {
+	case "list":
+		text.WriteString("📋 *DAFTAR USER*")
+	case "renew":
+		text.WriteString("🔄 *PERPANJANG AKUN*")
+	default:
+		text.WriteString("🗑️ *HAPUS AKUN*")
+	}
+	for _, f := range userFilters {
+		if f.Status == sel.Status && f.Status != "" {
+			text.WriteString("\nFilter: " + f.Label)
+		}
+	}
+	if sel.Search != "" {
+		text.WriteString(fmt.Sprintf("\nPencarian: `%s`", sel.Search))
+	}
+	text.WriteString("\n\n")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(result.Users) == 0 {
+		text.WriteString("_Tidak ada akun yang cocok._")
+	} else if sel.Action == "list" {
+		text.WriteString(fmt.Sprintf("Halaman %d/%d, total %d akun:\n\n", result.Page, result.Pages, result.Total))
+		for i, u := range result.Users {
+			line := fmt.Sprintf("%d. `%s` • %s • exp `%s`", (result.Page-1)*UsersPerPage+i+1, u.Password, u.Status, u.Expired)
+			if u.OwnerID != 0 {
+				line += fmt.Sprintf(" • owner `%d`", u.OwnerID)
+			}
+			text.WriteString(line + "\n")
+		}
+	} else {
+		text.WriteString(fmt.Sprintf("Pilih akun (Halaman %d/%d, total %d akun):", result.Page, result.Pages, result.Total))
+		for _, u := range result.Users {
+			label := fmt.Sprintf("🔑 %s (%s) • %s", u.Password, u.Status, u.Expired)
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(label, "select_"+sel.Action+":"+u.Password),
+			))
+		}
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if result.Page > 1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("⬅️ Sebelumnya", sel.pageData(result.Page-1)))
+	}
+	if result.Page < result.Pages {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("Selanjutnya ➡️", sel.pageData(result.Page+1)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+
+	// Tombol filter status, yang sedang aktif diberi tanda centang
+	var filters []tgbotapi.InlineKeyboardButton
+	for _, f := range userFilters {
+		label := f.Label
+		if f.Status == sel.Status {
+			label = "✅ " + label
+		}
+		filter := userSelection{Action: sel.Action, Status: f.Status, Search: sel.Search}
+		filters = append(filters, tgbotapi.NewInlineKeyboardButtonData(label, filter.pageData(1)))
+		if len(filters) == 3 {
+			rows = append(rows, filters)
+			filters = nil
+		}
+	}
+	if len(filters) > 0 {
+		rows = append(rows, filters)
+	}
+
+	search := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("🔍 Cari", fmt.Sprintf("search_%s:%s", sel.Action, sel.Status)),
+	}
+	if sel.Search != "" {
+		reset := userSelection{Action: sel.Action, Status: sel.Status}
+		search = append(search, tgbotapi.NewInlineKeyboardButtonData("✖️ Hapus Pencarian", reset.pageData(1)))
+	}
+	rows = append(rows, search)
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🔙 Kembali", "cancel")))
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	deleteLastMessage(bot, chatID)
+	sendAndTrack(bot, msg)
+}
+
+// handlePagination memproses callback dari userSelection.pageData
+func handlePagination(bot *tgbotapi.BotAPI, chatID int64, userID int64, data string, config *BotConfig) {
+	sel, ok := parseUserSelection(data)
+	if !ok {
+		return
+	}
+	showUserSelection(bot, chatID, userID, sel, config)
+}
+
+// startUserSearch meminta kata kunci untuk callback "search_<action>:<status>"
+func startUserSearch(bot *tgbotapi.BotAPI, chatID int64, userID int64, data string) {
+	parts := strings.SplitN(strings.TrimPrefix(data, "search_"), ":", 2)
+	if len(parts) != 2 {
+		return
+	}
+	sel := userSelection{Action: parts[0], Status: parts[1]}
+	if !sel.valid() {
+		return
+	}
+	flows.Start("search", userID, chatID, map[string]string{"action": sel.Action, "status": sel.Status})
+}
+
+// confirmDeleteUser meminta konfirmasi sebelum akun dihapus
+func confirmDeleteUser(bot *tgbotapi.BotAPI, chatID int64, userID int64, data string, config *BotConfig) {
+	username := strings.TrimPrefix(data, "select_delete:")
+	if !canManageAccount(config, userID, username) {
+		replyError(bot, chatID, "❌ Akun tidak ditemukan.")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ *KONFIRMASI HAPUS*\n\nYakin ingin menghapus akun `%s`? Akun yang dihapus tidak bisa dikembalikan.", username))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Ya, Hapus", "confirm_delete:"+username),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Batal", "cancel"),
+		),
+	)
+	deleteLastMessage(bot, chatID)
+	sendAndTrack(bot, msg)
+}
+
+// listUsers menampilkan semua akun (khusus Admin), halaman pertama tanpa filter
+func listUsers(bot *tgbotapi.BotAPI, chatID int64, userID int64, config *BotConfig) {
+	showUserSelection(bot, chatID, userID, userSelection{Action: "list", Page: 1}, config)
+}
+
+// systemInfo menampilkan informasi server dari API
+func systemInfo(bot *tgbotapi.BotAPI, chatID int64, config *BotConfig) {
+	info, err := api.Info()
+	if err != nil {
+		ui.APIError(chatID, "mengambil info server", err)
+		return
+	}
+	ipInfo, _ := getIpInfo()
+
+	domain := config.Domain
+	if domain == "" {
+		domain = info.Domain
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("📊 *INFO SERVER*\n\n"+
+		"╠═ 🌐 *Domain* : `%s`\n"+
+		"╠═ 📡 *IP Public* : `%s`\n"+
+		"╠═ 🏠 *IP Private* : `%s`\n"+
+		"╠═ 🔌 *Port* : `%s`\n"+
+		"╠═ ⚙️ *Service* : `%s`\n"+
+		"╠═ 🏙️ *Kota* : `%s`\n"+
+		"╠═ 🏢 *ISP* : `%s`",
+		domain, info.PublicIP, info.PrivateIP, info.Port, info.Service, ipInfo.City, ipInfo.Isp))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🔙 Kembali", "cancel")),
+	)
+	deleteLastMessage(bot, chatID)
+	sendAndTrack(bot, msg)
+}
+
+// showBackupRestoreMenu menampilkan pilihan backup dan restore
+func showBackupRestoreMenu(bot *tgbotapi.BotAPI, chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, "💾 *BACKUP & RESTORE*\n\n"+
+		"╠═ *Backup*: kirim file terenkripsi berisi konfigurasi ZiVPN, data user, dan konfigurasi bot.\n"+
+		"╠═ *Restore*: kirim kembali file backup, periksa ringkasan perubahannya, lalu konfirmasi.")
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬇️ Backup Data", "menu_backup_action"),
+			tgbotapi.NewInlineKeyboardButtonData("⬆️ Restore Data", "menu_restore_action"),
+		),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🔙 Kembali", "cancel")),
+	)
+	deleteLastMessage(bot, chatID)
+	sendAndTrack(bot, msg)
+}
+
+// cancelOperation membatalkan percakapan yang sedang berjalan dan kembali ke menu utama
+func cancelOperation(bot *tgbotapi.BotAPI, chatID int64, userID int64, config *BotConfig) {
+	resetState(userID)
+	showMainMenu(bot, chatID, config)
+}
+
+// toggleMode mengubah mode bot antara public dan private lalu menyimpannya
+func toggleMode(bot *tgbotapi.BotAPI, chatID int64, userID int64) {
+	var oldMode string
+	config, err := updateConfig(func(cfg *BotConfig) error {
+		oldMode = cfg.Mode
+		if cfg.Mode == "public" {
+			cfg.Mode = "private"
+		} else {
+			cfg.Mode = "public"
+		}
+		return nil
+	})
+	if err != nil {
+		replyError(bot, chatID, err.Error())
+		return
+	}
+
+	log.Printf("INFO: Mode bot diubah oleh %d: %s -> %s", userID, oldMode, config.Mode)
+	sendMessage(bot, chatID, fmt.Sprintf("✅ Mode bot sekarang *%s*.", config.Mode))
+	showMainMenu(bot, chatID, config)
+}
\ No newline at end of file
@@ -0,0 +1,3099 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zivpn/internal/apiclient"
+	"zivpn/internal/backup"
+	"zivpn/internal/botui"
+	"zivpn/internal/bulk"
+	"zivpn/internal/config"
+	"zivpn/internal/flow"
+	"zivpn/internal/session"
+	"zivpn/internal/storage"
+	"zivpn/internal/telegram"
+)
+
+// ==========================================
+// Constants & Configuration
+// ==========================================
+
+const (
+	ConfigDir     = config.Dir
+	BotConfigFile = config.BotConfigFile
+	ApiPortFile   = config.ApiPortFile
+	ApiKeyFile    = config.ApiKeyFile
+	PlansFile     = config.PlansFile
+	VouchersFile  = config.VouchersFile
+	WalletsFile   = config.WalletsFile
+	ResellersFile = config.ResellersFile
+	OrdersFile    = config.OrdersFile
+	AdminLogFile  = config.AdminLogFile
+	SnapshotDir   = config.SnapshotDir
+)
+
+// Admin permissions. The owner (AdminID) always has all of them.
+const (
+	PermUsers  = config.PermUsers  // manage accounts and customer balances
+	PermBackup = config.PermBackup // backup & restore
+	PermMode   = config.PermMode   // toggle public/private mode
+	PermSales  = config.PermSales  // view sales reports
+	PermPrices = config.PermPrices // manage plans, vouchers and resellers
+)
+
+var allPermissions = config.AllPermissions
+
+// MinTransaction is the smallest amount Pakasir accepts for QRIS
+const MinTransaction = 500
+
+// MaxTopup caps a single wallet top up
+const MaxTopup = 10000000
+
+var ApiUrl = config.ApiURL(config.DefaultApiPort)
+
+var ApiKey = config.DefaultApiKey
+
+// api is the ZiVPN API client, created once ApiUrl and ApiKey are loaded
+var api *apiclient.Client
+
+// InvoiceTTL is used when Pakasir does not return a parseable expired_at
+const InvoiceTTL = 1 * time.Hour
+
+type BotConfig struct {
+	config.Access // AdminID (owner) and additional Admins
+
+	BotToken       string `json:"bot_token"`
+	Mode           string `json:"mode"`
+	Domain         string `json:"domain"`
+	PakasirSlug    string `json:"pakasir_slug"`
+	PakasirApiKey  string `json:"pakasir_api_key"`
+	DailyPrice     int    `json:"daily_price"`
+	ReportTime     string `json:"report_time"` // "HH:MM" daily summary, "off" to disable
+	NotifyChatID   int64    `json:"notify_chat_id"` // order notifications, 0 = AdminID
+	NotifyEvents   []string `json:"notify_events"`  // empty = all events
+
+	backup.Schedule // automatic backups: backup_schedule, backup_keep, backup_chat_id
+}
+
+// Admin is a delegated admin with a subset of permissions
+type Admin = config.Admin
+
+type IpInfo struct {
+	City string `json:"city"`
+	Isp  string `json:"isp"`
+}
+
+// Plan is a purchasable package. Price is per term of Days days.
+type Plan struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Days      int            `json:"days"`
+	Price     int            `json:"price"`
+	QuotaGB   int            `json:"quota_gb"`
+	IpLimit   int            `json:"ip_limit"`
+	Discounts []PlanDiscount `json:"discounts,omitempty"`
+}
+
+// PlanDiscount applies Percent off when buying at least MinPeriods terms at once
+type PlanDiscount struct {
+	MinPeriods int `json:"min_periods"`
+	Percent    int `json:"percent"`
+}
+
+// Voucher is a promo code. Type is "percent" or "fixed"; zero limits mean unlimited.
+type Voucher struct {
+	Code       string         `json:"code"`
+	Type       string         `json:"type"`
+	Value      int            `json:"value"`
+	MaxUses    int            `json:"max_uses"`
+	PerUser    int            `json:"per_user"`
+	ValidFrom  string         `json:"valid_from,omitempty"`
+	ValidUntil string         `json:"valid_until,omitempty"`
+	Plans      []string       `json:"plans,omitempty"`
+	Used       int            `json:"used"`
+	UsedBy     map[string]int `json:"used_by,omitempty"`
+}
+
+// Wallet is the prepaid balance of one Telegram user
+type Wallet struct {
+	Balance int        `json:"balance"`
+	History []WalletTx `json:"history"`
+}
+
+// WalletTx is one balance mutation. Amount is negative for spending.
+type WalletTx struct {
+	Time    string `json:"time"`
+	Type    string `json:"type"`
+	Amount  int    `json:"amount"`
+	Balance int    `json:"balance"`
+	Note    string `json:"note,omitempty"`
+}
+
+// Reseller buys accounts for their own customers at a discount. Prices
+// overrides the per-term price of a plan; otherwise Discount percent applies.
+type Reseller struct {
+	ID       int64          `json:"id"`
+	Name     string         `json:"name"`
+	Discount int            `json:"discount"`
+	Prices   map[string]int `json:"prices,omitempty"`
+}
+
+// Order is the sales record of one purchase, renewal or top up.
+// Method is qris, wallet or voucher.
+type Order struct {
+	ID        string `json:"id"`
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username,omitempty"`
+	Action    string `json:"action"`
+	Password  string `json:"password,omitempty"`
+	PlanID    string `json:"plan_id,omitempty"`
+	Days      int    `json:"days,omitempty"`
+	Amount    int    `json:"amount"`
+	Voucher   string `json:"voucher,omitempty"`
+	Method    string `json:"method"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+	PaidAt    string `json:"paid_at,omitempty"`
+}
+
+// ==========================================
+// Global State
+// ==========================================
+
+var tempUserData = make(map[int64]map[string]string)
+
+// mutex guards tempUserData, which the payment checker also reads and takes
+// orders from
+var mutex = &sync.Mutex{}
+
+// storeMutex guards the JSON stores (plans, vouchers, wallets, resellers, orders) in /etc/zivpn
+var storeMutex = &sync.Mutex{}
+
+// botConfig is the active config. Handlers and the payment checker only read
+// the copy returned by currentConfig; updateConfig saves a changed copy and
+// swaps it in, so a copy in use is never modified.
+var botConfig atomic.Pointer[BotConfig]
+var configMutex sync.Mutex // serialises updateConfig
+
+// sessions holds the conversation state (flows, top up and voucher input),
+// the bulk commands, import files and backups waiting for confirmation, and
+// the menu message of each chat. It is shared with the payment checker
+// goroutine. Abandoned entries expire after FlowTTL.
+var sessions *session.Manager
+
+// ui sends the screens shared with the free bot (my accounts, bulk commands,
+// import/export, audit log, backup & restore)
+var ui *botui.UI
+
+// flows runs the step-by-step text inputs (new password, admin forms, restore)
+var flows *flow.Engine
+
+// FlowTTL ends abandoned flows
+const FlowTTL = 15 * time.Minute
+
+// Number of terms offered after a plan is chosen
+var periodOptions = []int{1, 2, 3, 6, 12}
+
+// ==========================================
+// Main Entry Point
+// ==========================================
+
+func main() {
+	key, err := config.LoadApiKey()
+	if err != nil {
+		log.Printf("Using default API key: %v", err)
+	}
+	ApiKey = key
+
+	// Load API Port
+	port, err := config.LoadApiPort()
+	if err != nil {
+		log.Printf("Using default API port: %v", err)
+	}
+	ApiUrl = config.ApiURL(port)
+	api = apiclient.New(ApiUrl, ApiKey)
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatal("Gagal memuat konfigurasi bot:", err)
+	}
+
+	bot, err := tgbotapi.NewBotAPI(config.BotToken)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bot.Debug = false
+	log.Printf("Authorized on account %s", bot.Self.UserName)
+	if notice := backup.BotRollbackNotice(SnapshotDir); notice != "" {
+		bot.Send(tgbotapi.NewMessage(config.AdminID, notice))
+	}
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+	u.Timeout = 60
+	updates := bot.GetUpdatesChan(u)
+
+	botConfig.Store(&config)
+	registerFlows(bot)
+	setupUI(bot)
+
+	// Start Payment Checker
+	go startPaymentChecker(bot)
+	go startDailyReport(bot, &config)
+	go ui.RunBackupSchedule(config.Schedule, config.AdminID)
+
+	for update := range updates {
+		if update.Message != nil {
+			handleMessage(bot, update.Message, currentConfig())
+		} else if update.CallbackQuery != nil {
+			handleCallback(bot, update.CallbackQuery, currentConfig())
+		}
+	}
+}
+
+// ==========================================
+// Telegram Event Handlers
+// ==========================================
+
+func handleMessage(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, config *BotConfig) {
+	// In Paid Bot, everyone can access, but actions are restricted/paid
+	// Admin still has full control
+
+	// Flows come first so the restore document reaches its step
+	if flows.HandleMessage(msg) {
+		return
+	}
+
+	// A CSV/JSON file from an admin is a user import
+	if msg.Document != nil && config.Can(msg.From.ID, PermUsers) {
+		if format := bulk.ImportFormat(msg.Document.FileName); format != "" {
+			ui.PreviewImport(msg, format)
+			return
+		}
+	}
+
+	if state, exists := sessions.State(msg.From.ID); exists {
+		handleState(bot, msg, state, config)
+		return
+	}
+
+	if msg.IsCommand() {
+		switch msg.Command() {
+		case "start":
+			showMainMenu(bot, msg.Chat.ID, config)
+		case "addadmin", "deladmin", "admins", "adminlog":
+			if msg.From.ID == config.AdminID {
+				handleAdminCommand(bot, msg, config)
+			}
+		case "audit":
+			if config.Can(msg.From.ID, PermUsers) {
+				ui.ShowAuditLog(msg.Chat.ID, msg.CommandArguments())
+			}
+		case "bulkcreate", "bulkextend", "bulkdelete", "bulklock", "bulkunlock", "compensate":
+			if config.Can(msg.From.ID, PermUsers) {
+				ui.HandleBulkCommand(msg)
+			}
+		case "export":
+			if config.Can(msg.From.ID, PermUsers) {
+				ui.ExportUsers(msg)
+			}
+		default:
+			replyError(bot, msg.Chat.ID, "Perintah tidak dikenal.")
+		}
+	}
+}
+
+func handleCallback(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery, config *BotConfig) {
+	chatID := query.Message.Chat.ID
+	userID := query.From.ID
+
+	if flows.HandleCallback(query) {
+		return
+	}
+	// Any other button leaves the flow in progress
+	flows.Stop(userID)
+
+	switch {
+	case query.Data == "menu_create":
+		startCreateUser(bot, chatID, userID, telegram.UserHandle(query.From), config)
+	case query.Data == "menu_renew":
+		showRenewSelection(bot, chatID, userID)
+	case strings.HasPrefix(query.Data, "pick:"), strings.HasPrefix(query.Data, "pick_search:"):
+		handlePick(bot, chatID, userID, query.Data)
+	case strings.HasPrefix(query.Data, "select_renew:"):
+		startRenewUser(bot, chatID, userID, telegram.UserHandle(query.From), strings.TrimPrefix(query.Data, "select_renew:"), config)
+	case strings.HasPrefix(query.Data, "select_plan:"):
+		selectPlan(bot, chatID, userID, strings.TrimPrefix(query.Data, "select_plan:"), config)
+	case strings.HasPrefix(query.Data, "select_period:"):
+		selectPeriod(bot, chatID, userID, strings.TrimPrefix(query.Data, "select_period:"), config)
+	case query.Data == "menu_reseller":
+		if findReseller(userID) != nil {
+			showResellerMenu(bot, chatID, userID)
+		}
+	case query.Data == "reseller_delete":
+		if findReseller(userID) != nil {
+			showResellerDeleteSelection(bot, chatID, userID)
+		}
+	case strings.HasPrefix(query.Data, "reseller_del:"):
+		if findReseller(userID) != nil {
+			confirmResellerDelete(bot, chatID, userID, strings.TrimPrefix(query.Data, "reseller_del:"))
+		}
+	case strings.HasPrefix(query.Data, "reseller_del_ok:"):
+		if findReseller(userID) != nil {
+			resellerDeleteUser(bot, chatID, userID, strings.TrimPrefix(query.Data, "reseller_del_ok:"))
+		}
+	case query.Data == "menu_my_accounts":
+		ui.ShowMyAccounts(chatID, userID)
+	case strings.HasPrefix(query.Data, "acc:"):
+		ui.ShowAccountDetail(chatID, userID, strings.TrimPrefix(query.Data, "acc:"))
+	case strings.HasPrefix(query.Data, "acc_info:"):
+		ui.ShowConnectionInfo(chatID, userID, strings.TrimPrefix(query.Data, "acc_info:"))
+	case strings.HasPrefix(query.Data, "acc_rotate:"):
+		ui.ConfirmRotatePassword(chatID, userID, strings.TrimPrefix(query.Data, "acc_rotate:"))
+	case strings.HasPrefix(query.Data, "acc_rotate_ok:"):
+		ui.RotatePassword(chatID, userID, strings.TrimPrefix(query.Data, "acc_rotate_ok:"))
+	case query.Data == "menu_wallet":
+		showWallet(bot, chatID, userID)
+	case query.Data == "wallet_topup":
+		startTopup(bot, chatID, userID, telegram.UserHandle(query.From), config)
+	case query.Data == "pay_qris":
+		payWithQris(bot, chatID, userID, config)
+	case query.Data == "pay_wallet":
+		payWithWallet(bot, chatID, userID, config)
+	case query.Data == "skip_voucher":
+		if sessions.ClearStateIf(userID, "enter_voucher") {
+			checkout(bot, chatID, userID, config)
+		}
+	case query.Data == "menu_info":
+		systemInfo(bot, chatID, config)
+	case query.Data == "cancel":
+		cancelOperation(bot, chatID, userID, config)
+	case query.Data == botui.CallbackBulk:
+		if config.Can(userID, PermUsers) {
+			ui.ConfirmBulk(chatID, userID)
+		}
+	case query.Data == botui.CallbackImport:
+		if config.Can(userID, PermUsers) {
+			ui.ConfirmImport(chatID, userID)
+		}
+	case query.Data == "cancel_order":
+		cancelOrder(bot, chatID, userID, config)
+
+	case query.Data == "menu_admin":
+		if config.IsAdmin(userID) {
+			showBackupRestoreMenu(bot, chatID, userID, config)
+		}
+	case query.Data == "menu_backup_action":
+		if config.Can(userID, PermBackup) {
+			logAdminAction(userID, "backup", "")
+			ui.SendBackup(chatID)
+		}
+	case query.Data == "menu_restore_action":
+		if config.Can(userID, PermBackup) {
+			startRestore(bot, chatID, userID)
+		}
+	case query.Data == botui.CallbackRestore:
+		if config.Can(userID, PermBackup) {
+			ui.ConfirmRestore(chatID, userID)
+		}
+	case query.Data == "admin_plans":
+		if config.Can(userID, PermPrices) {
+			showPlanAdmin(bot, chatID, config)
+		}
+	case query.Data == "admin_plan_add":
+		if config.Can(userID, PermPrices) {
+			flows.Start("admin_plan_add", userID, chatID, nil)
+		}
+	case query.Data == "admin_plan_discount":
+		if config.Can(userID, PermPrices) {
+			flows.Start("admin_plan_discount", userID, chatID, nil)
+		}
+	case strings.HasPrefix(query.Data, "admin_plan_del:"):
+		if config.Can(userID, PermPrices) {
+			logAdminAction(userID, "plan_delete", strings.TrimPrefix(query.Data, "admin_plan_del:"))
+			deletePlan(bot, chatID, strings.TrimPrefix(query.Data, "admin_plan_del:"), config)
+		}
+	case query.Data == "admin_vouchers":
+		if config.Can(userID, PermPrices) {
+			showVoucherAdmin(bot, chatID)
+		}
+	case query.Data == "admin_voucher_add":
+		if config.Can(userID, PermPrices) {
+			flows.Start("admin_voucher_add", userID, chatID, nil)
+		}
+	case query.Data == "admin_wallet":
+		if config.Can(userID, PermUsers) {
+			flows.Start("admin_wallet_adjust", userID, chatID, nil)
+		}
+	case query.Data == "admin_audit":
+		if config.Can(userID, PermUsers) {
+			ui.ShowAuditLog(chatID, "")
+		}
+	case query.Data == "admin_sales":
+		if config.Can(userID, PermSales) {
+			showSalesReport(bot, chatID)
+		}
+	case query.Data == "admin_sales_csv":
+		if config.Can(userID, PermSales) {
+			logAdminAction(userID, "sales_export", "")
+			exportOrdersCSV(bot, chatID)
+		}
+	case query.Data == "admin_resellers":
+		if config.Can(userID, PermPrices) {
+			showResellerAdmin(bot, chatID)
+		}
+	case query.Data == "admin_reseller_add":
+		if config.Can(userID, PermPrices) {
+			flows.Start("admin_reseller_add", userID, chatID, nil)
+		}
+	case query.Data == "admin_reseller_price":
+		if config.Can(userID, PermPrices) {
+			flows.Start("admin_reseller_price", userID, chatID, nil)
+		}
+	case strings.HasPrefix(query.Data, "admin_reseller_del:"):
+		if config.Can(userID, PermPrices) {
+			id, _ := strconv.ParseInt(strings.TrimPrefix(query.Data, "admin_reseller_del:"), 10, 64)
+			logAdminAction(userID, "reseller_delete", strconv.FormatInt(id, 10))
+			deleteReseller(bot, chatID, id)
+		}
+	case strings.HasPrefix(query.Data, "admin_voucher_del:"):
+		if config.Can(userID, PermPrices) {
+			logAdminAction(userID, "voucher_delete", strings.TrimPrefix(query.Data, "admin_voucher_del:"))
+			deleteVoucher(bot, chatID, strings.TrimPrefix(query.Data, "admin_voucher_del:"))
+		}
+	}
+
+	bot.Request(tgbotapi.NewCallback(query.ID, ""))
+}
+
+func handleState(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, state string, config *BotConfig) {
+	userID := msg.From.ID
+	text := strings.TrimSpace(msg.Text)
+	chatID := msg.Chat.ID
+
+	switch state {
+	case "enter_voucher":
+		mutex.Lock()
+		data, ok := pendingSelection(bot, chatID, userID)
+		mutex.Unlock()
+		if !ok {
+			sessions.ClearState(userID)
+			return
+		}
+		price, _ := strconv.Atoi(data["base_price"])
+		code, discount, err := validateVoucher(text, userID, data["plan_id"], price)
+		if err != nil {
+			sendMessage(bot, chatID, "❌ "+err.Error()+"\nKirim kode lain atau tekan Lewati.")
+			return
+		}
+		mutex.Lock()
+		data["voucher"] = code
+		data["discount"] = strconv.Itoa(discount)
+		mutex.Unlock()
+		sessions.ClearState(userID)
+		checkout(bot, chatID, userID, config)
+
+	case "topup_amount":
+		amount, ok := validateNumber(bot, chatID, text, MinTransaction, MaxTopup, "Nominal")
+		if !ok {
+			return
+		}
+		processPayment(bot, chatID, userID, 0, amount, config)
+	}
+}
+
+// ==========================================
+// Flows
+// ==========================================
+
+// adminInput declares a one-step admin form. apply parses and saves the answer;
+// its error is shown and the form asked again.
+func adminInput(name, perm, prompt string, apply func(ctx *flow.Context) error) *flow.Flow {
+	return &flow.Flow{
+		Name:  name,
+		Steps: []flow.Step{{Key: "input", Prompt: prompt}},
+		Finish: func(ctx *flow.Context) error {
+			// Permissions may have been revoked while the form was open
+			if !currentConfig().Can(ctx.UserID, perm) {
+				return nil
+			}
+			if err := apply(ctx); err != nil {
+				return fmt.Errorf("%v. Coba lagi:", err)
+			}
+			logAdminAction(ctx.UserID, name, ctx.Data["input"])
+			return nil
+		},
+	}
+}
+
+func registerFlows(bot *tgbotapi.BotAPI) {
+	sessions = session.NewManager(FlowTTL, "")
+	sessions.StartJanitor(time.Minute, func(userID int64, s session.Session) {
+		if s.State != "" && s.ChatID != 0 {
+			sendMessage(bot, s.ChatID, "⌛ Sesi berakhir karena tidak ada aktivitas. Ketik /start untuk mulai lagi.")
+		}
+	})
+
+	flows = flow.New(bot, sessions)
+	flows.ParseMode = ""
+	flows.Send = func(msg tgbotapi.MessageConfig) { sendAndTrack(bot, msg) }
+
+	flows.Register(&flow.Flow{
+		Name:  "create",
+		Steps: []flow.Step{{Key: "password", Prompt: "👤 Masukkan Password Baru:", Validate: flow.Password}},
+		Finish: func(ctx *flow.Context) error {
+			mutex.Lock()
+			data, ok := tempUserData[ctx.UserID]
+			if ok {
+				data["password"] = ctx.Data["password"]
+			}
+			mutex.Unlock()
+			if !ok {
+				replyError(bot, ctx.ChatID, "Sesi berakhir. Silakan ulangi dari menu utama.")
+				return nil
+			}
+			showPlanSelection(bot, ctx.ChatID, ctx.UserID, currentConfig())
+			return nil
+		},
+		OnCancel: func(ctx *flow.Context) {
+			cancelOperation(bot, ctx.ChatID, ctx.UserID, currentConfig())
+		},
+	})
+
+	flows.Register(&flow.Flow{
+		Name:  "account_search",
+		Steps: []flow.Step{{Key: "q", Prompt: "🔍 Masukkan kata kunci password yang dicari (maks 20 karakter):", Validate: flow.Keyword}},
+		Finish: func(ctx *flow.Context) error {
+			showAccountPicker(bot, ctx.ChatID, ctx.UserID, ctx.Data["action"], 1, ctx.Data["q"])
+			return nil
+		},
+		OnCancel: func(ctx *flow.Context) {
+			cancelOperation(bot, ctx.ChatID, ctx.UserID, currentConfig())
+		},
+	})
+
+	flows.Register(&flow.Flow{
+		Name: "restore",
+		Steps: []flow.Step{{Key: "file", Document: true,
+			Prompt: "⬆️ Restore Data\n\nSilakan kirim file backup (.zvbak) Anda sekarang.\n\n⚠️ PERINGATAN: Data saat ini akan ditimpa!"}},
+		Finish: func(ctx *flow.Context) error {
+			// Permissions may have been revoked while waiting for the file
+			if currentConfig().Can(ctx.UserID, PermBackup) {
+				ui.PreviewRestore(ctx.Message)
+			}
+			return nil
+		},
+	})
+
+	for _, f := range []*flow.Flow{
+		adminInput("admin_plan_add", PermPrices, "➕ Kirim paket dengan format:\nid|nama|hari|harga|kuota_gb|ip_limit\n\nContoh: bulanan|Bulanan|30|15000|0|2\nHarga minimal Rp "+strconv.Itoa(MinTransaction)+". Kuota/IP 0 = tanpa batas. ID yang sudah ada akan diperbarui.",
+			func(ctx *flow.Context) error {
+				if err := upsertPlan(ctx.Data["input"], currentConfig()); err != nil {
+					return err
+				}
+				showPlanAdmin(bot, ctx.ChatID, currentConfig())
+				return nil
+			}),
+		adminInput("admin_plan_discount", PermPrices, "🏷 Kirim diskon dengan format:\nid|min_periode|persen\n\nContoh: bulanan|3|10 (diskon 10% untuk pembelian minimal 3 periode).\nPersen 0 = hapus diskon.",
+			func(ctx *flow.Context) error {
+				if err := setPlanDiscount(ctx.Data["input"], currentConfig()); err != nil {
+					return err
+				}
+				showPlanAdmin(bot, ctx.ChatID, currentConfig())
+				return nil
+			}),
+		adminInput("admin_voucher_add", PermPrices, "🎟 Kirim voucher dengan format:\nKODE|percent/fixed|nilai|maks_pakai|per_user|berlaku_dari|berlaku_sampai|paket\n\nContoh: HEMAT10|percent|10|100|1|2025-01-01|2025-01-31|bulanan,mingguan\nGratis 100%: FREE|percent|100|5|1\nField setelah per_user opsional, isi - untuk tanpa batas. Tanggal format YYYY-MM-DD.",
+			func(ctx *flow.Context) error {
+				if err := addVoucher(ctx.Data["input"]); err != nil {
+					return err
+				}
+				showVoucherAdmin(bot, ctx.ChatID)
+				return nil
+			}),
+		adminInput("admin_wallet_adjust", PermUsers, "💰 Kirim penyesuaian saldo dengan format:\nuser_id|jumlah|catatan\n\nContoh: 123456789|10000|bonus\nGunakan jumlah negatif untuk mengurangi saldo.",
+			func(ctx *flow.Context) error {
+				target, balance, err := adjustWallet(ctx.Data["input"])
+				if err != nil {
+					return err
+				}
+				sendMessage(bot, ctx.ChatID, fmt.Sprintf("✅ Saldo %d sekarang Rp %d", target, balance))
+				showBackupRestoreMenu(bot, ctx.ChatID, ctx.UserID, currentConfig())
+				return nil
+			}),
+		adminInput("admin_reseller_add", PermPrices, "🏪 Kirim reseller dengan format:\nuser_id|nama|diskon_persen\n\nContoh: 123456789|Toko Budi|20\nID yang sudah ada akan diperbarui.",
+			func(ctx *flow.Context) error {
+				if err := upsertReseller(ctx.Data["input"]); err != nil {
+					return err
+				}
+				showResellerAdmin(bot, ctx.ChatID)
+				return nil
+			}),
+		adminInput("admin_reseller_price", PermPrices, "💲 Kirim harga khusus dengan format:\nuser_id|paket_id|harga_per_periode\n\nContoh: 123456789|bulanan|10000\nHarga 0 = hapus harga khusus.",
+			func(ctx *flow.Context) error {
+				if err := setResellerPrice(ctx.Data["input"]); err != nil {
+					return err
+				}
+				showResellerAdmin(bot, ctx.ChatID)
+				return nil
+			}),
+	} {
+		flows.Register(f)
+	}
+}
+
+// ==========================================
+// Feature Implementation
+// ==========================================
+
+func startCreateUser(bot *tgbotapi.BotAPI, chatID int64, userID int64, handle string, config *BotConfig) {
+	// A new purchase replaces any invoice that is still waiting for payment
+	if !voidOrder(bot, config, chatID, userID) {
+		return
+	}
+	mutex.Lock()
+	tempUserData[userID] = make(map[string]string)
+	tempUserData[userID]["chat_id"] = strconv.FormatInt(chatID, 10)
+	tempUserData[userID]["username"] = handle
+	mutex.Unlock()
+	flows.Start("create", userID, chatID, nil)
+}
+
+// AccountsPerPage is the page size of the account pickers
+const AccountsPerPage = 10
+
+// accountPicker describes one account picker: its title, the callback prefix
+// of the account buttons and where the back button leads
+type accountPicker struct {
+	Title, Select, Back string
+}
+
+var accountPickers = map[string]accountPicker{
+	"renew":  {"🔄 *Perpanjang Akun*\nPilih akun yang ingin diperpanjang:", "select_renew:", "cancel"},
+	"resdel": {"🗑 *Hapus Akun*\nPilih akun yang ingin dihapus:", "reseller_del:", "menu_reseller"},
+}
+
+func showRenewSelection(bot *tgbotapi.BotAPI, chatID int64, userID int64) {
+	showAccountPicker(bot, chatID, userID, "renew", 1, "")
+}
+
+// showAccountPicker lists the user's own accounts a page at a time. Search
+// and paging are done by the API; buttons carry "pick:<action>:<page>:<search>".
+func showAccountPicker(bot *tgbotapi.BotAPI, chatID int64, userID int64, action string, page int, search string) {
+	picker, ok := accountPickers[action]
+	if !ok {
+		return
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	opts := apiclient.ListOptions{OwnerID: userID, Search: search, Sort: "password", Page: page, Limit: AccountsPerPage}
+	result, err := api.ListUsersPage(opts)
+	if err == nil && len(result.Users) == 0 && result.Total > 0 {
+		// The page is gone, e.g. after a delete, so show the last one
+		opts.Page = result.Pages
+		result, err = api.ListUsersPage(opts)
+	}
+	if err != nil {
+		ui.APIError(chatID, "mengambil daftar akun", err)
+		return
+	}
+
+	if result.Total == 0 && search == "" {
+		msg := tgbotapi.NewMessage(chatID, "ℹ️ Anda belum memiliki akun yang dibeli melalui bot ini.")
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Kembali", picker.Back)),
+		)
+		sendAndTrack(bot, msg)
+		return
+	}
+
+	text := picker.Title
+	if search != "" {
+		text += fmt.Sprintf("\nPencarian: `%s`", search)
+	}
+	if result.Total == 0 {
+		text += "\n\n_Tidak ada akun yang cocok._"
+	} else if result.Pages > 1 {
+		text += fmt.Sprintf("\n\nHalaman %d/%d, total %d akun", result.Page, result.Pages, result.Total)
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, u := range result.Users {
+		label := fmt.Sprintf("%s (%s) - %s", u.Password, u.Status, u.Expired)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, picker.Select+u.Password),
+		))
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if result.Page > 1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("⬅️ Sebelumnya", fmt.Sprintf("pick:%s:%d:%s", action, result.Page-1, search)))
+	}
+	if result.Page < result.Pages {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("Selanjutnya ➡️", fmt.Sprintf("pick:%s:%d:%s", action, result.Page+1, search)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+
+	searchRow := []tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardButtonData("🔍 Cari", "pick_search:"+action)}
+	if search != "" {
+		searchRow = append(searchRow, tgbotapi.NewInlineKeyboardButtonData("✖️ Hapus Pencarian", "pick:"+action+":1:"))
+	}
+	rows = append(rows, searchRow)
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Kembali", picker.Back)))
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	sendAndTrack(bot, msg)
+}
+
+// handlePick handles the "pick:" paging and "pick_search:" buttons. The
+// reseller picker is only open to resellers.
+func handlePick(bot *tgbotapi.BotAPI, chatID int64, userID int64, data string) {
+	if action := strings.TrimPrefix(data, "pick_search:"); action != data {
+		if _, ok := accountPickers[action]; ok && (action != "resdel" || findReseller(userID) != nil) {
+			flows.Start("account_search", userID, chatID, map[string]string{"action": action})
+		}
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(data, "pick:"), ":", 3)
+	if len(parts) != 3 {
+		return
+	}
+	page, err := strconv.Atoi(parts[1])
+	if err != nil || (parts[0] == "resdel" && findReseller(userID) == nil) {
+		return
+	}
+	showAccountPicker(bot, chatID, userID, parts[0], page, parts[2])
+}
+
+func startRenewUser(bot *tgbotapi.BotAPI, chatID int64, userID int64, handle string, password string, config *BotConfig) {
+	// Callback data comes from the client, so ownership is checked again here
+	if _, ok := ui.FindOwnedAccount(userID, password); !ok {
+		replyError(bot, chatID, "Akun tidak ditemukan.")
+		return
+	}
+
+	if !voidOrder(bot, config, chatID, userID) {
+		return
+	}
+	mutex.Lock()
+	tempUserData[userID] = make(map[string]string)
+	tempUserData[userID]["chat_id"] = strconv.FormatInt(chatID, 10)
+	tempUserData[userID]["username"] = handle
+	tempUserData[userID]["action"] = "renew"
+	tempUserData[userID]["password"] = password
+	mutex.Unlock()
+	showPlanSelection(bot, chatID, userID, config)
+}
+
+func showPlanSelection(bot *tgbotapi.BotAPI, chatID int64, userID int64, config *BotConfig) {
+	plans, err := loadPlans(config)
+	if err != nil || len(plans) == 0 {
+		replyError(bot, chatID, "Belum ada paket yang tersedia. Silakan hubungi admin.")
+		return
+	}
+
+	reseller := findReseller(userID)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, p := range plans {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s - %d Hari - Rp %d", p.Name, p.Days, p.priceFor(1, reseller)), "select_plan:"+p.ID),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Batal", "cancel")))
+
+	msg := tgbotapi.NewMessage(chatID, "📦 *Pilih Paket*")
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	sendAndTrack(bot, msg)
+}
+
+// pendingSelection returns the purchase data of userID if a plan may still be chosen
+func pendingSelection(bot *tgbotapi.BotAPI, chatID int64, userID int64) (map[string]string, bool) {
+	data, ok := tempUserData[userID]
+	if !ok || data["password"] == "" {
+		replyError(bot, chatID, "Sesi pembelian berakhir. Silakan ulangi dari menu utama.")
+		return nil, false
+	}
+	if data["order_id"] != "" {
+		replyError(bot, chatID, "Selesaikan atau batalkan tagihan sebelumnya terlebih dahulu.")
+		return nil, false
+	}
+	return data, true
+}
+
+func selectPlan(bot *tgbotapi.BotAPI, chatID int64, userID int64, planID string, config *BotConfig) {
+	mutex.Lock()
+	data, ok := pendingSelection(bot, chatID, userID)
+	mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	plans, _ := loadPlans(config)
+	plan, found := findPlan(plans, planID)
+	if !found {
+		replyError(bot, chatID, "Paket tidak ditemukan.")
+		return
+	}
+
+	mutex.Lock()
+	data["plan_id"] = plan.ID
+	mutex.Unlock()
+
+	reseller := findReseller(userID)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, n := range periodOptions {
+		label := fmt.Sprintf("%dx (%d Hari) - Rp %d", n, n*plan.Days, plan.priceFor(n, reseller))
+		if pct := plan.discountFor(n); pct > 0 {
+			label += fmt.Sprintf(" (-%d%%)", pct)
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, "select_period:"+strconv.Itoa(n)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Batal", "cancel")))
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⏳ *%s*\nPilih jumlah periode:", plan.Name))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	sendAndTrack(bot, msg)
+}
+
+func selectPeriod(bot *tgbotapi.BotAPI, chatID int64, userID int64, periodStr string, config *BotConfig) {
+	mutex.Lock()
+	data, ok := pendingSelection(bot, chatID, userID)
+	var planID string
+	if ok {
+		planID = data["plan_id"]
+	}
+	mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	periods, err := strconv.Atoi(periodStr)
+	if err != nil || periods < 1 {
+		return
+	}
+	plans, _ := loadPlans(config)
+	plan, found := findPlan(plans, planID)
+	if !found {
+		replyError(bot, chatID, "Paket tidak ditemukan.")
+		return
+	}
+
+	days := plan.Days * periods
+	price := plan.priceFor(periods, findReseller(userID))
+	mutex.Lock()
+	data["days"] = strconv.Itoa(days)
+	data["base_price"] = strconv.Itoa(price)
+	data["ip_limit"] = strconv.Itoa(plan.IpLimit)
+	data["quota_gb"] = strconv.Itoa(plan.QuotaGB)
+	delete(data, "voucher")
+	delete(data, "discount")
+	mutex.Unlock()
+
+	sessions.SetState(userID, chatID, "enter_voucher")
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🎟 Total: Rp %d\nPunya kode promo? Kirim kodenya sekarang, atau tekan Lewati.", price))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⏭ Lewati", "skip_voucher"),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Batal", "cancel"),
+		),
+	)
+	sendAndTrack(bot, msg)
+}
+
+// checkout applies the chosen voucher and either bills the rest through
+// Pakasir or, for fully discounted orders, fulfils them right away. Those
+// leave tempUserData first, so they are fulfilled once and without mutex.
+func checkout(bot *tgbotapi.BotAPI, chatID int64, userID int64, config *BotConfig) {
+	mutex.Lock()
+	data, ok := pendingSelection(bot, chatID, userID)
+	if !ok {
+		mutex.Unlock()
+		return
+	}
+	days, _ := strconv.Atoi(data["days"])
+	price, _ := strconv.Atoi(data["base_price"])
+	discount, _ := strconv.Atoi(data["discount"])
+	price -= discount
+	if price < 0 {
+		price = 0
+	}
+
+	if price == 0 && discount == 0 {
+		// Only a voucher may make an order free, never a misconfigured price
+		replyError(bot, chatID, "Harga paket tidak valid. Silakan hubungi admin.")
+		delete(tempUserData, userID)
+		mutex.Unlock()
+		return
+	}
+	if price == 0 {
+		delete(tempUserData, userID)
+		mutex.Unlock()
+		if !reserveOrderVoucher(bot, chatID, userID, data["voucher"]) {
+			return
+		}
+		data["price"] = "0"
+		data["order_id"] = newOrderID(userID)
+		data["method"] = "voucher"
+		recordOrder(userID, data, "paid")
+		notifyOrder(bot, config, "paid", userID, data)
+		fulfillOrder(bot, config, userID, data)
+		return
+	}
+	data["amount"] = strconv.Itoa(price)
+	mutex.Unlock()
+
+	balance := walletBalance(userID)
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("💳 *Pilih Metode Pembayaran*\n\nDurasi: %d Hari\nTotal: Rp %d\nSaldo Anda: Rp %d", days, price, balance))
+	msg.ParseMode = "Markdown"
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("💳 Bayar QRIS", "pay_qris")),
+	}
+	if balance >= price {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("💰 Bayar dengan Saldo", "pay_wallet")))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Batal", "cancel")))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	sendAndTrack(bot, msg)
+}
+
+func payWithQris(bot *tgbotapi.BotAPI, chatID int64, userID int64, config *BotConfig) {
+	mutex.Lock()
+	data, ok := pendingSelection(bot, chatID, userID)
+	var days, price int
+	if ok {
+		days, _ = strconv.Atoi(data["days"])
+		price, _ = strconv.Atoi(data["amount"])
+	}
+	mutex.Unlock()
+	if !ok || price <= 0 {
+		return
+	}
+
+	processPayment(bot, chatID, userID, days, price, config)
+}
+
+// payWithWallet debits the balance first and refunds it if fulfilment fails.
+// The order leaves tempUserData before the debit, so a second tap cannot pay
+// it twice and mutex is not held while the account is made.
+func payWithWallet(bot *tgbotapi.BotAPI, chatID int64, userID int64, config *BotConfig) {
+	mutex.Lock()
+	data, ok := pendingSelection(bot, chatID, userID)
+	var price int
+	if ok {
+		price, _ = strconv.Atoi(data["amount"])
+	}
+	if !ok || price <= 0 {
+		mutex.Unlock()
+		return
+	}
+	delete(tempUserData, userID)
+	mutex.Unlock()
+
+	txType := "purchase"
+	if data["action"] == "renew" {
+		txType = "renew"
+	}
+	note := fmt.Sprintf("%s %s hari", data["password"], data["days"])
+	if !reserveOrderVoucher(bot, chatID, userID, data["voucher"]) {
+		return
+	}
+	if _, err := walletApply(userID, -price, txType, note); err != nil {
+		if data["voucher"] != "" {
+			releaseVoucher(data["voucher"], userID)
+		}
+		replyError(bot, chatID, err.Error())
+		return
+	}
+
+	data["price"] = data["amount"]
+	data["order_id"] = newOrderID(userID)
+	data["method"] = "wallet"
+	recordOrder(userID, data, "paid")
+	notifyOrder(bot, config, "paid", userID, data)
+	if !fulfillOrder(bot, config, userID, data) {
+		if _, err := walletApply(userID, price, "refund", note); err != nil {
+			log.Printf("Error refunding wallet of %d: %v", userID, err)
+		} else {
+			updateOrderStatus(data["order_id"], "refunded")
+			sendMessage(bot, chatID, fmt.Sprintf("↩️ Saldo Rp %d telah dikembalikan.", price))
+		}
+	}
+}
+
+// processPayment creates a QRIS invoice for the order in tempUserData. The
+// order ID is set before Pakasir is called, so a second tap cannot create
+// another invoice, and the entry is looked up again afterwards because the
+// user may have cancelled it in the meantime.
+func processPayment(bot *tgbotapi.BotAPI, chatID int64, userID int64, days int, price int, config *BotConfig) {
+	if price < MinTransaction {
+		sendMessage(bot, chatID, fmt.Sprintf("❌ Total harga Rp %d. Minimal transaksi adalah Rp %d.\nSilakan pilih periode yang lebih panjang.", price, MinTransaction))
+		return
+	}
+	orderID := newOrderID(userID)
+
+	mutex.Lock()
+	data, ok := tempUserData[userID]
+	if !ok || data["order_id"] != "" {
+		mutex.Unlock()
+		replyError(bot, chatID, "Sesi pembelian berakhir. Silakan ulangi dari menu utama.")
+		return
+	}
+	data["order_id"] = orderID
+	password, action := data["password"], data["action"]
+	voucher, discount := data["voucher"], data["discount"]
+	mutex.Unlock()
+
+	if !reserveOrderVoucher(bot, chatID, userID, voucher) {
+		mutex.Lock()
+		takeOrder(userID, orderID)
+		mutex.Unlock()
+		return
+	}
+
+	// Call Pakasir API
+	payment, err := createPakasirTransaction(config, orderID, price)
+	mutex.Lock()
+	data, ok = tempUserData[userID]
+	owned := ok && data["order_id"] == orderID
+	if err != nil && owned {
+		delete(data, "order_id")
+	}
+	var invoice map[string]string
+	if err == nil && owned {
+		// Store Order ID for verification
+		data["price"] = strconv.Itoa(price)
+		data["expired_at"] = payment.ExpiredAt
+		data["created_at"] = strconv.FormatInt(time.Now().Unix(), 10)
+		data["method"] = "qris"
+		// The checker may take the order as soon as mutex is released, so
+		// the store and Telegram get a copy
+		invoice = make(map[string]string, len(data))
+		for k, v := range data {
+			invoice[k] = v
+		}
+	}
+	mutex.Unlock()
+	if invoice != nil {
+		recordOrder(userID, invoice, "pending")
+		notifyOrder(bot, config, "invoice_created", userID, invoice)
+	}
+
+	if err != nil {
+		// An order voided meanwhile already gave its voucher back
+		if owned && voucher != "" {
+			releaseVoucher(voucher, userID)
+		}
+		replyError(bot, chatID, "Gagal membuat pembayaran: "+err.Error())
+		resetState(userID)
+		return
+	}
+	if !owned {
+		if err := cancelPakasirTransaction(config, orderID, strconv.Itoa(price)); err != nil {
+			log.Printf("Error cancelling payment %s: %v", orderID, err)
+		}
+		return
+	}
+
+	// Generate QR Image URL
+	qrUrl := fmt.Sprintf("https://api.qrserver.com/v1/create-qr-code/?size=300x300&data=%s", payment.PaymentNumber)
+
+	title := "Tagihan Pembayaran"
+	details := fmt.Sprintf("Password: `%s`\nDurasi: %d Hari\n", password, days)
+	switch action {
+	case "renew":
+		title = "Tagihan Perpanjangan"
+	case "topup":
+		title = "Tagihan Top Up Saldo"
+		details = ""
+	}
+	if voucher != "" {
+		details += fmt.Sprintf("Voucher: `%s` (-Rp %s)\n", voucher, discount)
+	}
+	msgText := fmt.Sprintf("💳 **%s**\n\n%sTotal: Rp %d\n\nSilakan scan QRIS di atas untuk membayar.\nSistem akan otomatis mengecek pembayaran setiap menit.\nExpired: %s",
+		title, details, price, payment.ExpiredAt)
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(qrUrl))
+	photo.Caption = msgText
+	photo.ParseMode = "Markdown"
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Batal", "cancel_order"),
+		),
+	)
+	photo.ReplyMarkup = keyboard
+
+	deleteLastMessage(bot, chatID)
+	sentMsg, err := bot.Send(photo)
+	if err == nil {
+		sessions.SetLastMessage(chatID, sentMsg.MessageID)
+		mutex.Lock()
+		if data, ok := tempUserData[userID]; ok && data["order_id"] == orderID {
+			data["message_id"] = strconv.Itoa(sentMsg.MessageID)
+		}
+		mutex.Unlock()
+	}
+
+	// Clear state but keep tempUserData for verification
+	sessions.ClearState(userID)
+}
+
+// reserveOrderVoucher reserves a use of the voucher of an order, if it has
+// one, and tells the user to start again when it can no longer be used
+func reserveOrderVoucher(bot *tgbotapi.BotAPI, chatID int64, userID int64, code string) bool {
+	if code == "" {
+		return true
+	}
+	if err := reserveVoucher(code, userID); err != nil {
+		replyError(bot, chatID, fmt.Sprintf("Voucher %s tidak dapat digunakan: %v. Silakan ulangi pesanan.", code, err))
+		return false
+	}
+	return true
+}
+
+func startPaymentChecker(bot *tgbotapi.BotAPI) {
+	ticker := time.NewTicker(1 * time.Minute)
+	for range ticker.C {
+		checkPendingOrders(bot, currentConfig())
+	}
+}
+
+// pendingOrder is what the payment checker needs to know about an invoice
+type pendingOrder struct {
+	userID  int64
+	orderID string
+	price   string
+	expiry  time.Time
+}
+
+// checkPendingOrders asks Pakasir about every open invoice. The invoices are
+// copied under mutex and checked without it; an order is then taken out of
+// tempUserData before it is fulfilled or voided, so an order the user
+// cancelled or replaced in the meantime is left alone. An order whose status
+// cannot be read, or which Pakasir refuses to cancel, is kept and checked
+// again on the next tick, so a payment made just before expiry is not lost.
+func checkPendingOrders(bot *tgbotapi.BotAPI, config *BotConfig) {
+	mutex.Lock()
+	var orders []pendingOrder
+	for userID, data := range tempUserData {
+		// price is only set once the invoice exists at Pakasir
+		if data["order_id"] != "" && data["price"] != "" {
+			orders = append(orders, pendingOrder{userID, data["order_id"], data["price"], orderExpiry(data)})
+		}
+	}
+	mutex.Unlock()
+
+	for _, o := range orders {
+		status, err := checkPakasirStatus(config, o.orderID, o.price)
+		var reason string
+		switch {
+		case err != nil:
+			log.Printf("Error checking payment for %d: %v", o.userID, err)
+			continue
+		case status == "completed" || status == "success":
+			reason = "paid"
+		case status == "expired" || status == "canceled" || status == "cancelled":
+			// Already void upstream, only local cleanup is needed
+			reason = "Kedaluwarsa"
+		case time.Now().After(o.expiry):
+			if err := cancelPakasirTransaction(config, o.orderID, o.price); err != nil {
+				log.Printf("Error cancelling expired payment %s: %v", o.orderID, err)
+				continue
+			}
+			reason = "Kedaluwarsa"
+		default:
+			continue
+		}
+
+		mutex.Lock()
+		data, ok := takeOrder(o.userID, o.orderID)
+		mutex.Unlock()
+		if !ok {
+			continue
+		}
+		if reason == "paid" {
+			updateOrderStatus(o.orderID, "paid")
+			notifyOrder(bot, config, "paid", o.userID, data)
+			fulfillOrder(bot, config, o.userID, data)
+		} else {
+			closeOrder(bot, config, o.userID, data, reason)
+		}
+	}
+}
+
+// fulfillOrder creates or renews the account of a paid order, or credits a
+// top up. The voucher use reserved for the order is released if it fails.
+// data must already be out of tempUserData; mutex is not needed.
+func fulfillOrder(bot *tgbotapi.BotAPI, config *BotConfig, userID int64, data map[string]string) bool {
+	chatID, _ := strconv.ParseInt(data["chat_id"], 10, 64)
+	password := data["password"]
+	days, _ := strconv.Atoi(data["days"])
+	ipLimit, _ := strconv.Atoi(data["ip_limit"])
+	quotaGB, _ := strconv.Atoi(data["quota_gb"])
+
+	var ok bool
+	switch data["action"] {
+	case "topup":
+		amount, _ := strconv.Atoi(data["price"])
+		balance, err := walletApply(userID, amount, "topup", data["order_id"])
+		if err != nil {
+			log.Printf("Error crediting top up %s for %d: %v", data["order_id"], userID, err)
+			replyError(bot, chatID, "Top up diterima tetapi saldo gagal ditambahkan. Silakan hubungi admin dengan Order ID "+data["order_id"])
+			updateOrderStatus(data["order_id"], "failed")
+			notifyOrder(bot, config, "failed", userID, data)
+			return false
+		}
+		deleteLastMessage(bot, chatID)
+		sendMessage(bot, chatID, fmt.Sprintf("✅ Top up Rp %d berhasil. Saldo Anda sekarang Rp %d.", amount, balance))
+		showMainMenu(bot, chatID, config)
+		ok = true
+	case "renew":
+		ok = renewUser(bot, chatID, userID, password, days, config)
+	default:
+		ok = createUser(bot, chatID, userID, password, days, ipLimit, quotaGB, config)
+	}
+
+	if ok {
+		updateOrderStatus(data["order_id"], "fulfilled")
+		notifyOrder(bot, config, "fulfilled", userID, data)
+	} else {
+		updateOrderStatus(data["order_id"], "failed")
+		notifyOrder(bot, config, "failed", userID, data)
+	}
+
+	if !ok && data["voucher"] != "" {
+		releaseVoucher(data["voucher"], userID)
+	}
+	return ok
+}
+
+func createUser(bot *tgbotapi.BotAPI, chatID int64, ownerID int64, password string, days int, ipLimit int, quotaGB int, config *BotConfig) bool {
+	acc, err := api.As(ownerID).CreateUser(apiclient.CreateUserRequest{
+		Password: password,
+		Days:     days,
+		OwnerID:  ownerID,
+		IpLimit:  ipLimit,
+		QuotaGB:  quotaGB,
+	})
+	if err != nil {
+		ui.APIError(chatID, "membuat akun", err)
+		return false
+	}
+
+	sendAccountInfo(bot, chatID, acc, config)
+	return true
+}
+
+// orderExpiry returns the moment a pending invoice stops being payable
+func orderExpiry(data map[string]string) time.Time {
+	if expiredAt, err := time.Parse(time.RFC3339, data["expired_at"]); err == nil {
+		return expiredAt
+	}
+	createdAt, _ := strconv.ParseInt(data["created_at"], 10, 64)
+	return time.Unix(createdAt, 0).Add(InvoiceTTL)
+}
+
+// voidOrder cancels the pending order of userID, if any, at Pakasir and then
+// closes it with closeOrder. When Pakasir refuses, the order is kept so the
+// payment checker still settles it if it was paid, the user is told and false
+// is returned. Caller must not hold mutex.
+func voidOrder(bot *tgbotapi.BotAPI, config *BotConfig, chatID int64, userID int64) bool {
+	mutex.Lock()
+	data := tempUserData[userID]
+	orderID, price := data["order_id"], data["price"]
+	mutex.Unlock()
+	if orderID == "" {
+		return true
+	}
+
+	// price is only set once the invoice exists at Pakasir
+	if price != "" {
+		if err := cancelPakasirTransaction(config, orderID, price); err != nil {
+			log.Printf("Error cancelling payment %s: %v", orderID, err)
+			replyError(bot, chatID, "Tagihan sebelumnya gagal dibatalkan. Silakan coba lagi sebentar lagi; jika sudah dibayar, pesanan akan diproses otomatis.")
+			return false
+		}
+	}
+
+	mutex.Lock()
+	data, ok := takeOrder(userID, orderID)
+	mutex.Unlock()
+	if ok {
+		closeOrder(bot, config, userID, data, "Dibatalkan")
+	}
+	return true
+}
+
+// takeOrder removes the pending order of userID from tempUserData and returns
+// it, so the user and the payment checker cannot both close it. An orderID
+// other than "" must match the pending order. Caller must hold mutex.
+func takeOrder(userID int64, orderID string) (map[string]string, bool) {
+	data, ok := tempUserData[userID]
+	if !ok || data["order_id"] == "" || (orderID != "" && data["order_id"] != orderID) {
+		return nil, false
+	}
+	delete(tempUserData, userID)
+	// A flow the user started in the meantime is kept
+	sessions.ClearStateIf(userID, "topup_amount", "enter_voucher")
+	return data, true
+}
+
+// closeOrder marks the QR message of a taken order, already void at Pakasir,
+// as no longer valid and releases its voucher
+func closeOrder(bot *tgbotapi.BotAPI, config *BotConfig, userID int64, data map[string]string, reason string) {
+	orderID := data["order_id"]
+
+	chatID, _ := strconv.ParseInt(data["chat_id"], 10, 64)
+	if msgID, err := strconv.Atoi(data["message_id"]); err == nil {
+		caption := fmt.Sprintf("🚫 *Tagihan %s*\n\nOrder: `%s`\nTotal: Rp %s\n\nQRIS ini sudah tidak berlaku, jangan lakukan pembayaran.",
+			reason, orderID, data["price"])
+		edit := tgbotapi.NewEditMessageCaption(chatID, msgID, caption)
+		edit.ParseMode = "Markdown"
+		edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+		if _, err := bot.Request(edit); err != nil {
+			log.Printf("Error editing invoice message %d: %v", msgID, err)
+		}
+
+		// Keep the void invoice visible instead of deleting it with the next menu
+		sessions.ForgetLastMessage(chatID, msgID)
+	}
+
+	if data["voucher"] != "" {
+		releaseVoucher(data["voucher"], userID)
+	}
+
+	log.Printf("Order %s for %d %s", orderID, userID, strings.ToLower(reason))
+	if reason == "Dibatalkan" {
+		updateOrderStatus(orderID, "cancelled")
+		notifyOrder(bot, config, "cancelled", userID, data)
+	} else {
+		updateOrderStatus(orderID, "expired")
+		notifyOrder(bot, config, "expired", userID, data)
+	}
+}
+
+func renewUser(bot *tgbotapi.BotAPI, chatID int64, userID int64, password string, days int, config *BotConfig) bool {
+	// Buyers only renew their own accounts, which the API checks again
+	acc, err := api.As(userID).RenewUser(apiclient.RenewUserRequest{
+		Password: password,
+		Days:     days,
+		OwnerID:  userID,
+	})
+	if err != nil {
+		ui.APIError(chatID, "memperpanjang akun", err)
+		return false
+	}
+
+	sendAccountInfo(bot, chatID, acc, config)
+	return true
+}
+
+// ==========================================
+// Plan Catalogue
+// ==========================================
+
+func loadPlans(config *BotConfig) ([]Plan, error) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	return readPlans(config)
+}
+
+func readPlans(config *BotConfig) ([]Plan, error) {
+	var plans []Plan
+	err := storage.ReadJSON(PlansFile, &plans)
+	if os.IsNotExist(err) {
+		return defaultPlans(config), nil
+	}
+	return plans, err
+}
+
+func writePlans(plans []Plan) error {
+	return storage.WriteJSON(PlansFile, plans, 0644)
+}
+
+// defaultPlans keeps installs without plans.json selling at DailyPrice
+func defaultPlans(config *BotConfig) []Plan {
+	if config.DailyPrice <= 0 {
+		return nil
+	}
+	return []Plan{
+		{ID: "mingguan", Name: "Mingguan", Days: 7, Price: 7 * config.DailyPrice},
+		{ID: "bulanan", Name: "Bulanan", Days: 30, Price: 30 * config.DailyPrice},
+	}
+}
+
+func findPlan(plans []Plan, id string) (Plan, bool) {
+	for _, p := range plans {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Plan{}, false
+}
+
+// discountFor returns the highest discount percentage reached by periods
+func (p Plan) discountFor(periods int) int {
+	percent := 0
+	for _, d := range p.Discounts {
+		if periods >= d.MinPeriods && d.Percent > percent {
+			percent = d.Percent
+		}
+	}
+	return percent
+}
+
+// priceFor returns the total for periods terms, at reseller prices when given
+func (p Plan) priceFor(periods int, reseller *Reseller) int {
+	unit := p.Price
+	if reseller != nil {
+		if price, ok := reseller.Prices[p.ID]; ok && price > 0 {
+			unit = price
+		} else {
+			unit = p.Price * (100 - reseller.Discount) / 100
+		}
+	}
+	total := unit * periods
+	return total - total*p.discountFor(periods)/100
+}
+
+var planIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,16}$`)
+
+// upsertPlan parses "id|nama|hari|harga|kuota_gb|ip_limit" and stores the plan
+func upsertPlan(text string, config *BotConfig) error {
+	parts := strings.Split(text, "|")
+	if len(parts) < 4 || len(parts) > 6 {
+		return fmt.Errorf("Format salah")
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	plan := Plan{ID: strings.ToLower(parts[0]), Name: parts[1]}
+	if !planIDPattern.MatchString(plan.ID) {
+		return fmt.Errorf("ID hanya boleh huruf kecil, angka, - dan _ (maks 16)")
+	}
+	if plan.Name == "" {
+		return fmt.Errorf("Nama paket wajib diisi")
+	}
+	var err error
+	if plan.Days, err = strconv.Atoi(parts[2]); err != nil || plan.Days < 1 || plan.Days > 365 {
+		return fmt.Errorf("Hari harus angka 1-365")
+	}
+	if plan.Price, err = strconv.Atoi(parts[3]); err != nil || plan.Price < MinTransaction {
+		return fmt.Errorf("Harga minimal Rp %d", MinTransaction)
+	}
+	if len(parts) > 4 {
+		if plan.QuotaGB, err = strconv.Atoi(parts[4]); err != nil || plan.QuotaGB < 0 {
+			return fmt.Errorf("Kuota harus angka positif")
+		}
+	}
+	if len(parts) > 5 {
+		if plan.IpLimit, err = strconv.Atoi(parts[5]); err != nil || plan.IpLimit < 0 {
+			return fmt.Errorf("IP limit harus angka positif")
+		}
+	}
+
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	plans, err := readPlans(config)
+	if err != nil {
+		return fmt.Errorf("Gagal membaca paket")
+	}
+
+	replaced := false
+	for i, p := range plans {
+		if p.ID == plan.ID {
+			plan.Discounts = p.Discounts
+			plans[i] = plan
+			replaced = true
+		}
+	}
+	if !replaced {
+		plans = append(plans, plan)
+	}
+	if err := writePlans(plans); err != nil {
+		return fmt.Errorf("Gagal menyimpan paket")
+	}
+	return nil
+}
+
+// setPlanDiscount parses "id|min_periode|persen"; persen 0 removes the tier
+func setPlanDiscount(text string, config *BotConfig) error {
+	parts := strings.Split(text, "|")
+	if len(parts) != 3 {
+		return fmt.Errorf("Format salah")
+	}
+	id := strings.ToLower(strings.TrimSpace(parts[0]))
+	minPeriods, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || minPeriods < 1 {
+		return fmt.Errorf("Minimal periode harus angka positif")
+	}
+	percent, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+	if err != nil || percent < 0 || percent > 100 {
+		return fmt.Errorf("Persen harus angka 0-100")
+	}
+
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	plans, err := readPlans(config)
+	if err != nil {
+		return fmt.Errorf("Gagal membaca paket")
+	}
+
+	found := false
+	for i, p := range plans {
+		if p.ID != id {
+			continue
+		}
+		found = true
+		discounts := []PlanDiscount{}
+		for _, d := range p.Discounts {
+			if d.MinPeriods != minPeriods {
+				discounts = append(discounts, d)
+			}
+		}
+		if percent > 0 {
+			discounts = append(discounts, PlanDiscount{MinPeriods: minPeriods, Percent: percent})
+		}
+		plans[i].Discounts = discounts
+	}
+	if !found {
+		return fmt.Errorf("Paket tidak ditemukan")
+	}
+	if err := writePlans(plans); err != nil {
+		return fmt.Errorf("Gagal menyimpan paket")
+	}
+	return nil
+}
+
+func deletePlan(bot *tgbotapi.BotAPI, chatID int64, id string, config *BotConfig) {
+	storeMutex.Lock()
+	plans, err := readPlans(config)
+	if err == nil {
+		remaining := []Plan{}
+		for _, p := range plans {
+			if p.ID != id {
+				remaining = append(remaining, p)
+			}
+		}
+		err = writePlans(remaining)
+	}
+	storeMutex.Unlock()
+
+	if err != nil {
+		replyError(bot, chatID, "Gagal menghapus paket.")
+		return
+	}
+	showPlanAdmin(bot, chatID, config)
+}
+
+func showPlanAdmin(bot *tgbotapi.BotAPI, chatID int64, config *BotConfig) {
+	plans, err := loadPlans(config)
+	if err != nil {
+		replyError(bot, chatID, "Gagal membaca paket.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📦 *Kelola Paket*\n\n")
+	if len(plans) == 0 {
+		sb.WriteString("_Belum ada paket._\n")
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, p := range plans {
+		sb.WriteString(fmt.Sprintf("• `%s` %s: %d hari, Rp %d, kuota %d GB, IP %d\n", p.ID, p.Name, p.Days, p.Price, p.QuotaGB, p.IpLimit))
+		for _, d := range p.Discounts {
+			sb.WriteString(fmt.Sprintf("   └ min %d periode: -%d%%\n", d.MinPeriods, d.Percent))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Hapus "+p.Name, "admin_plan_del:"+p.ID),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ Tambah / Ubah", "admin_plan_add"),
+			tgbotapi.NewInlineKeyboardButtonData("🏷 Atur Diskon", "admin_plan_discount"),
+		),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Kembali", "menu_admin")),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	sendAndTrack(bot, msg)
+}
+
+// ==========================================
+// Vouchers
+// ==========================================
+
+func readVouchers() ([]Voucher, error) {
+	var vouchers []Voucher
+	err := storage.LoadJSON(VouchersFile, &vouchers)
+	return vouchers, err
+}
+
+func writeVouchers(vouchers []Voucher) error {
+	return storage.WriteJSON(VouchersFile, vouchers, 0644)
+}
+
+// discountFor returns how much v takes off price
+func (v Voucher) discountFor(price int) int {
+	discount := v.Value
+	if v.Type == "percent" {
+		discount = price * v.Value / 100
+	}
+	if discount > price {
+		discount = price
+	}
+	return discount
+}
+
+// validateVoucher checks whether userID may use code on planID and returns the
+// normalized code with the discount for price. Usage is only counted by
+// reserveVoucher, once the order is billed.
+func validateVoucher(code string, userID int64, planID string, price int) (string, int, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	vouchers, err := readVouchers()
+	if err != nil {
+		return "", 0, fmt.Errorf("Gagal membaca voucher")
+	}
+
+	for _, v := range vouchers {
+		if v.Code != code {
+			continue
+		}
+		if err := v.usable(userID); err != nil {
+			return "", 0, err
+		}
+		if len(v.Plans) > 0 {
+			allowed := false
+			for _, p := range v.Plans {
+				if p == planID {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return "", 0, fmt.Errorf("Voucher tidak berlaku untuk paket ini")
+			}
+		}
+		return v.Code, v.discountFor(price), nil
+	}
+	return "", 0, fmt.Errorf("Kode voucher tidak ditemukan")
+}
+
+// usable checks the validity dates and usage caps of v for userID
+func (v Voucher) usable(userID int64) error {
+	today := time.Now().Format("2006-01-02")
+	if v.ValidFrom != "" && today < v.ValidFrom {
+		return fmt.Errorf("Voucher belum berlaku")
+	}
+	if v.ValidUntil != "" && today > v.ValidUntil {
+		return fmt.Errorf("Voucher sudah kedaluwarsa")
+	}
+	if v.MaxUses > 0 && v.Used >= v.MaxUses {
+		return fmt.Errorf("Kuota voucher sudah habis")
+	}
+	if v.PerUser > 0 && v.UsedBy[strconv.FormatInt(userID, 10)] >= v.PerUser {
+		return fmt.Errorf("Anda sudah mencapai batas pemakaian voucher ini")
+	}
+	return nil
+}
+
+// reserveVoucher counts one use of code for userID when its order is billed.
+// The caps are checked again in the same critical section, so two orders
+// entered at the same time cannot both take the last use. releaseVoucher
+// gives the use back when the order is voided or fails.
+func reserveVoucher(code string, userID int64) error {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	vouchers, err := readVouchers()
+	if err != nil {
+		return fmt.Errorf("Gagal membaca voucher")
+	}
+	for i, v := range vouchers {
+		if v.Code == code {
+			if err := v.usable(userID); err != nil {
+				return err
+			}
+			if v.UsedBy == nil {
+				vouchers[i].UsedBy = make(map[string]int)
+			}
+			vouchers[i].Used++
+			vouchers[i].UsedBy[strconv.FormatInt(userID, 10)]++
+			if err := writeVouchers(vouchers); err != nil {
+				return fmt.Errorf("Gagal menyimpan voucher")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("Kode voucher tidak ditemukan")
+}
+
+// releaseVoucher undoes reserveVoucher
+func releaseVoucher(code string, userID int64) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	vouchers, err := readVouchers()
+	if err != nil {
+		log.Printf("Error releasing voucher %s for %d: %v", code, userID, err)
+		return
+	}
+	user := strconv.FormatInt(userID, 10)
+	for i, v := range vouchers {
+		if v.Code != code {
+			continue
+		}
+		if v.Used > 0 {
+			vouchers[i].Used--
+		}
+		if v.UsedBy[user] > 1 {
+			vouchers[i].UsedBy[user]--
+		} else {
+			delete(vouchers[i].UsedBy, user)
+		}
+		if err := writeVouchers(vouchers); err != nil {
+			log.Printf("Error releasing voucher %s for %d: %v", code, userID, err)
+		}
+		return
+	}
+}
+
+var voucherCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,20}$`)
+
+// addVoucher parses "KODE|type|nilai|maks_pakai|per_user|dari|sampai|paket,paket"
+func addVoucher(text string) error {
+	parts := strings.Split(text, "|")
+	if len(parts) < 5 || len(parts) > 8 {
+		return fmt.Errorf("Format salah")
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+		if parts[i] == "-" {
+			parts[i] = ""
+		}
+	}
+
+	v := Voucher{Code: strings.ToUpper(parts[0]), Type: strings.ToLower(parts[1])}
+	if !voucherCodePattern.MatchString(v.Code) {
+		return fmt.Errorf("Kode harus 3-20 karakter huruf, angka, - atau _")
+	}
+	if v.Type != "percent" && v.Type != "fixed" {
+		return fmt.Errorf("Tipe harus percent atau fixed")
+	}
+	var err error
+	if v.Value, err = strconv.Atoi(parts[2]); err != nil || v.Value <= 0 || (v.Type == "percent" && v.Value > 100) {
+		return fmt.Errorf("Nilai tidak valid")
+	}
+	if v.MaxUses, err = strconv.Atoi(parts[3]); err != nil || v.MaxUses < 0 {
+		return fmt.Errorf("Maks pakai harus angka (0 = tanpa batas)")
+	}
+	if v.PerUser, err = strconv.Atoi(parts[4]); err != nil || v.PerUser < 0 {
+		return fmt.Errorf("Per user harus angka (0 = tanpa batas)")
+	}
+	if len(parts) > 5 && parts[5] != "" {
+		if _, err := time.Parse("2006-01-02", parts[5]); err != nil {
+			return fmt.Errorf("Tanggal mulai harus YYYY-MM-DD")
+		}
+		v.ValidFrom = parts[5]
+	}
+	if len(parts) > 6 && parts[6] != "" {
+		if _, err := time.Parse("2006-01-02", parts[6]); err != nil {
+			return fmt.Errorf("Tanggal akhir harus YYYY-MM-DD")
+		}
+		v.ValidUntil = parts[6]
+	}
+	if len(parts) > 7 && parts[7] != "" {
+		for _, p := range strings.Split(parts[7], ",") {
+			if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+				v.Plans = append(v.Plans, p)
+			}
+		}
+	}
+
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	vouchers, err := readVouchers()
+	if err != nil {
+		return fmt.Errorf("Gagal membaca voucher")
+	}
+	for _, existing := range vouchers {
+		if existing.Code == v.Code {
+			return fmt.Errorf("Kode %s sudah ada", v.Code)
+		}
+	}
+	vouchers = append(vouchers, v)
+	if err := writeVouchers(vouchers); err != nil {
+		return fmt.Errorf("Gagal menyimpan voucher")
+	}
+	return nil
+}
+
+func deleteVoucher(bot *tgbotapi.BotAPI, chatID int64, code string) {
+	storeMutex.Lock()
+	vouchers, err := readVouchers()
+	if err == nil {
+		remaining := []Voucher{}
+		for _, v := range vouchers {
+			if v.Code != code {
+				remaining = append(remaining, v)
+			}
+		}
+		err = writeVouchers(remaining)
+	}
+	storeMutex.Unlock()
+
+	if err != nil {
+		replyError(bot, chatID, "Gagal menghapus voucher.")
+		return
+	}
+	showVoucherAdmin(bot, chatID)
+}
+
+func showVoucherAdmin(bot *tgbotapi.BotAPI, chatID int64) {
+	storeMutex.Lock()
+	vouchers, err := readVouchers()
+	storeMutex.Unlock()
+	if err != nil {
+		replyError(bot, chatID, "Gagal membaca voucher.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🎟 *Kelola Voucher*\n\n")
+	if len(vouchers) == 0 {
+		sb.WriteString("_Belum ada voucher._\n")
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, v := range vouchers {
+		value := fmt.Sprintf("Rp %d", v.Value)
+		if v.Type == "percent" {
+			value = fmt.Sprintf("%d%%", v.Value)
+		}
+		maxUses := "∞"
+		if v.MaxUses > 0 {
+			maxUses = strconv.Itoa(v.MaxUses)
+		}
+		sb.WriteString(fmt.Sprintf("• `%s` %s, dipakai %d/%s", v.Code, value, v.Used, maxUses))
+		if v.PerUser > 0 {
+			sb.WriteString(fmt.Sprintf(", %dx/user", v.PerUser))
+		}
+		if v.ValidFrom != "" || v.ValidUntil != "" {
+			sb.WriteString(fmt.Sprintf(", %s s/d %s", v.ValidFrom, v.ValidUntil))
+		}
+		if len(v.Plans) > 0 {
+			sb.WriteString(", paket: `" + strings.Join(v.Plans, ",") + "`")
+		}
+		sb.WriteString("\n")
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Hapus "+v.Code, "admin_voucher_del:"+v.Code),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("➕ Buat Voucher", "admin_voucher_add")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Kembali", "menu_admin")),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	sendAndTrack(bot, msg)
+}
+
+// ==========================================
+// Wallet
+// ==========================================
+
+func readWallets() (map[string]Wallet, error) {
+	wallets := make(map[string]Wallet)
+	err := storage.LoadJSON(WalletsFile, &wallets)
+	return wallets, err
+}
+
+func writeWallets(wallets map[string]Wallet) error {
+	return storage.WriteJSON(WalletsFile, wallets, 0644)
+}
+
+func loadWallet(userID int64) (Wallet, error) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	wallets, err := readWallets()
+	if err != nil {
+		return Wallet{}, err
+	}
+	return wallets[strconv.FormatInt(userID, 10)], nil
+}
+
+func walletBalance(userID int64) int {
+	wallet, err := loadWallet(userID)
+	if err != nil {
+		log.Printf("Error reading wallet of %d: %v", userID, err)
+		return 0
+	}
+	return wallet.Balance
+}
+
+// walletApply adds amount (negative to spend) to the balance of userID and
+// returns the new balance. The balance never goes below zero.
+func walletApply(userID int64, amount int, txType string, note string) (int, error) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	wallets, err := readWallets()
+	if err != nil {
+		return 0, fmt.Errorf("Gagal membaca saldo")
+	}
+
+	key := strconv.FormatInt(userID, 10)
+	wallet := wallets[key]
+	if wallet.Balance+amount < 0 {
+		return wallet.Balance, fmt.Errorf("Saldo tidak mencukupi (Rp %d)", wallet.Balance)
+	}
+	wallet.Balance += amount
+	wallet.History = append(wallet.History, WalletTx{
+		Time:    time.Now().Format("2006-01-02 15:04:05"),
+		Type:    txType,
+		Amount:  amount,
+		Balance: wallet.Balance,
+		Note:    note,
+	})
+	wallets[key] = wallet
+
+	if err := writeWallets(wallets); err != nil {
+		return 0, fmt.Errorf("Gagal menyimpan saldo")
+	}
+	return wallet.Balance, nil
+}
+
+// adjustWallet parses "user_id|jumlah|catatan" from the admin panel
+func adjustWallet(text string) (int64, int, error) {
+	parts := strings.SplitN(text, "|", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("Format salah")
+	}
+	target, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("User ID tidak valid")
+	}
+	amount, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || amount == 0 {
+		return 0, 0, fmt.Errorf("Jumlah tidak valid")
+	}
+	note := "admin"
+	if len(parts) == 3 && strings.TrimSpace(parts[2]) != "" {
+		note = strings.TrimSpace(parts[2])
+	}
+
+	balance, err := walletApply(target, amount, "adjust", note)
+	return target, balance, err
+}
+
+func showWallet(bot *tgbotapi.BotAPI, chatID int64, userID int64) {
+	wallet, err := loadWallet(userID)
+	if err != nil {
+		replyError(bot, chatID, "Gagal membaca saldo.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("💰 *Saldo Anda*: Rp %d\n\n", wallet.Balance))
+	if len(wallet.History) == 0 {
+		sb.WriteString("_Belum ada transaksi._")
+	} else {
+		sb.WriteString("*Riwayat Terakhir*\n")
+		start := len(wallet.History) - 10
+		if start < 0 {
+			start = 0
+		}
+		for i := len(wallet.History) - 1; i >= start; i-- {
+			tx := wallet.History[i]
+			sb.WriteString(fmt.Sprintf("`%s` %s %+d\n", tx.Time, tx.Type, tx.Amount))
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("➕ Top Up", "wallet_topup")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Kembali", "cancel")),
+	)
+	sendAndTrack(bot, msg)
+}
+
+func startTopup(bot *tgbotapi.BotAPI, chatID int64, userID int64, handle string, config *BotConfig) {
+	if !voidOrder(bot, config, chatID, userID) {
+		return
+	}
+	mutex.Lock()
+	tempUserData[userID] = map[string]string{
+		"chat_id":  strconv.FormatInt(chatID, 10),
+		"username": handle,
+		"action":   "topup",
+	}
+	mutex.Unlock()
+	sessions.SetState(userID, chatID, "topup_amount")
+	sendMessage(bot, chatID, fmt.Sprintf("➕ Masukkan nominal top up (Rp %d - Rp %d):", MinTransaction, MaxTopup))
+}
+
+// ==========================================
+// Resellers
+// ==========================================
+
+func readResellers() ([]Reseller, error) {
+	var resellers []Reseller
+	err := storage.LoadJSON(ResellersFile, &resellers)
+	return resellers, err
+}
+
+func writeResellers(resellers []Reseller) error {
+	return storage.WriteJSON(ResellersFile, resellers, 0644)
+}
+
+// findReseller returns the reseller record of userID, or nil for customers
+func findReseller(userID int64) *Reseller {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	resellers, err := readResellers()
+	if err != nil {
+		log.Printf("Error reading resellers: %v", err)
+		return nil
+	}
+	for _, r := range resellers {
+		if r.ID == userID {
+			return &r
+		}
+	}
+	return nil
+}
+
+// upsertReseller parses "user_id|nama|diskon_persen" from the admin panel
+func upsertReseller(text string) error {
+	parts := strings.Split(text, "|")
+	if len(parts) != 3 {
+		return fmt.Errorf("Format salah")
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+	if err != nil {
+		return fmt.Errorf("User ID tidak valid")
+	}
+	name := strings.TrimSpace(parts[1])
+	if name == "" {
+		return fmt.Errorf("Nama wajib diisi")
+	}
+	discount, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+	if err != nil || discount < 0 || discount > 100 {
+		return fmt.Errorf("Diskon harus angka 0-100")
+	}
+
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	resellers, err := readResellers()
+	if err != nil {
+		return fmt.Errorf("Gagal membaca reseller")
+	}
+	replaced := false
+	for i, r := range resellers {
+		if r.ID == id {
+			resellers[i].Name = name
+			resellers[i].Discount = discount
+			replaced = true
+		}
+	}
+	if !replaced {
+		resellers = append(resellers, Reseller{ID: id, Name: name, Discount: discount})
+	}
+	if err := writeResellers(resellers); err != nil {
+		return fmt.Errorf("Gagal menyimpan reseller")
+	}
+	return nil
+}
+
+// setResellerPrice parses "user_id|paket_id|harga"; harga 0 removes the override
+func setResellerPrice(text string) error {
+	parts := strings.Split(text, "|")
+	if len(parts) != 3 {
+		return fmt.Errorf("Format salah")
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+	if err != nil {
+		return fmt.Errorf("User ID tidak valid")
+	}
+	planID := strings.ToLower(strings.TrimSpace(parts[1]))
+	price, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+	if err != nil || price < 0 {
+		return fmt.Errorf("Harga harus angka positif")
+	}
+
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	resellers, err := readResellers()
+	if err != nil {
+		return fmt.Errorf("Gagal membaca reseller")
+	}
+	for i, r := range resellers {
+		if r.ID != id {
+			continue
+		}
+		if resellers[i].Prices == nil {
+			resellers[i].Prices = make(map[string]int)
+		}
+		if price == 0 {
+			delete(resellers[i].Prices, planID)
+		} else {
+			resellers[i].Prices[planID] = price
+		}
+		if err := writeResellers(resellers); err != nil {
+			return fmt.Errorf("Gagal menyimpan reseller")
+		}
+		return nil
+	}
+	return fmt.Errorf("Reseller tidak ditemukan")
+}
+
+func deleteReseller(bot *tgbotapi.BotAPI, chatID int64, id int64) {
+	storeMutex.Lock()
+	resellers, err := readResellers()
+	if err == nil {
+		remaining := []Reseller{}
+		for _, r := range resellers {
+			if r.ID != id {
+				remaining = append(remaining, r)
+			}
+		}
+		err = writeResellers(remaining)
+	}
+	storeMutex.Unlock()
+
+	if err != nil {
+		replyError(bot, chatID, "Gagal menghapus reseller.")
+		return
+	}
+	showResellerAdmin(bot, chatID)
+}
+
+// resellerSales sums what a reseller spent on accounts, net of refunds
+func resellerSales(wallet Wallet) (int, int) {
+	total, count := 0, 0
+	for _, tx := range wallet.History {
+		switch tx.Type {
+		case "purchase", "renew":
+			total -= tx.Amount
+			count++
+		case "refund":
+			total -= tx.Amount
+			count--
+		}
+	}
+	return total, count
+}
+
+func showResellerAdmin(bot *tgbotapi.BotAPI, chatID int64) {
+	storeMutex.Lock()
+	resellers, err := readResellers()
+	wallets, walletErr := readWallets()
+	storeMutex.Unlock()
+	if err != nil || walletErr != nil {
+		replyError(bot, chatID, "Gagal membaca data reseller.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🏪 *Kelola Reseller*\n\n")
+	if len(resellers) == 0 {
+		sb.WriteString("_Belum ada reseller._\n")
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, r := range resellers {
+		accounts := "?"
+		if users, err := api.ListUsers(apiclient.ListOptions{OwnerID: r.ID}); err == nil {
+			accounts = strconv.Itoa(len(users))
+		}
+		wallet := wallets[strconv.FormatInt(r.ID, 10)]
+		sales, orders := resellerSales(wallet)
+		sb.WriteString(fmt.Sprintf("• *%s* (`%d`) diskon %d%%\n   Akun: %s, Transaksi: %d, Penjualan: Rp %d, Saldo: Rp %d\n",
+			r.Name, r.ID, r.Discount, accounts, orders, sales, wallet.Balance))
+		for planID, price := range r.Prices {
+			sb.WriteString(fmt.Sprintf("   └ `%s`: Rp %d\n", planID, price))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Hapus "+r.Name, fmt.Sprintf("admin_reseller_del:%d", r.ID)),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ Tambah / Ubah", "admin_reseller_add"),
+			tgbotapi.NewInlineKeyboardButtonData("💲 Harga Khusus", "admin_reseller_price"),
+		),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Kembali", "menu_admin")),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	sendAndTrack(bot, msg)
+}
+
+func showResellerMenu(bot *tgbotapi.BotAPI, chatID int64, userID int64) {
+	// Counted with the API's own rule: not locked and not past the expiry date
+	users, _ := api.ListUsers(apiclient.ListOptions{OwnerID: userID, Status: "active"})
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🏪 *Panel Reseller*\n\nAkun aktif Anda: %d\nSaldo: Rp %d\n\nHarga pada menu beli & perpanjang sudah memakai harga reseller.",
+		len(users), walletBalance(userID)))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ Buat Akun", "menu_create"),
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Perpanjang", "menu_renew"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Hapus Akun", "reseller_delete"),
+			tgbotapi.NewInlineKeyboardButtonData("💰 Saldo", "menu_wallet"),
+		),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Kembali", "cancel")),
+	)
+	sendAndTrack(bot, msg)
+}
+
+func showResellerDeleteSelection(bot *tgbotapi.BotAPI, chatID int64, userID int64) {
+	showAccountPicker(bot, chatID, userID, "resdel", 1, "")
+}
+
+func confirmResellerDelete(bot *tgbotapi.BotAPI, chatID int64, userID int64, password string) {
+	if _, ok := ui.FindOwnedAccount(userID, password); !ok {
+		replyError(bot, chatID, "Akun tidak ditemukan.")
+		return
+	}
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ Hapus akun `%s`? Sisa masa aktif tidak dikembalikan.", password))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Ya, Hapus", "reseller_del_ok:"+password),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Batal", "menu_reseller"),
+		),
+	)
+	sendAndTrack(bot, msg)
+}
+
+func resellerDeleteUser(bot *tgbotapi.BotAPI, chatID int64, userID int64, password string) {
+	// Callback data comes from the client, so ownership is checked again here
+	if _, ok := ui.FindOwnedAccount(userID, password); !ok {
+		replyError(bot, chatID, "Akun tidak ditemukan.")
+		return
+	}
+
+	err := api.As(userID).DeleteUser(apiclient.DeleteUserRequest{
+		Password: password,
+		OwnerID:  userID,
+	})
+	if err != nil {
+		ui.APIError(chatID, "menghapus akun", err)
+		return
+	}
+
+	log.Printf("Reseller %d deleted account %s", userID, password)
+	sendMessage(bot, chatID, fmt.Sprintf("✅ Akun %s berhasil dihapus.", password))
+	showResellerMenu(bot, chatID, userID)
+}
+
+// ==========================================
+// Orders & Sales Reports
+// ==========================================
+
+// newOrderID returns a unique order ID. The random suffix keeps two orders of
+// one user within the same second apart.
+func newOrderID(userID int64) string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return fmt.Sprintf("ZIVPN-%d-%d-%s", userID, time.Now().Unix(), hex.EncodeToString(suffix))
+}
+
+func readOrders() ([]Order, error) {
+	var orders []Order
+	err := storage.LoadJSON(OrdersFile, &orders)
+	return orders, err
+}
+
+func writeOrders(orders []Order) error {
+	return storage.WriteJSON(OrdersFile, orders, 0644)
+}
+
+func loadOrders() ([]Order, error) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	return readOrders()
+}
+
+// recordOrder appends the order described by the purchase data of userID
+func recordOrder(userID int64, data map[string]string, status string) {
+	days, _ := strconv.Atoi(data["days"])
+	amount, _ := strconv.Atoi(data["price"])
+	action := data["action"]
+	if action == "" {
+		action = "create"
+	}
+	order := Order{
+		ID:        data["order_id"],
+		UserID:    userID,
+		Username:  data["username"],
+		Action:    action,
+		Password:  data["password"],
+		PlanID:    data["plan_id"],
+		Days:      days,
+		Amount:    amount,
+		Voucher:   data["voucher"],
+		Method:    data["method"],
+		Status:    status,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if status == "paid" {
+		order.PaidAt = order.CreatedAt
+	}
+
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	orders, err := readOrders()
+	if err == nil {
+		err = writeOrders(append(orders, order))
+	}
+	if err != nil {
+		log.Printf("Error recording order %s: %v", order.ID, err)
+	}
+}
+
+func updateOrderStatus(orderID string, status string) {
+	if orderID == "" {
+		return
+	}
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	orders, err := readOrders()
+	if err != nil {
+		log.Printf("Error reading orders: %v", err)
+		return
+	}
+	for i := range orders {
+		if orders[i].ID == orderID {
+			orders[i].Status = status
+			if status == "paid" {
+				orders[i].PaidAt = time.Now().Format("2006-01-02 15:04:05")
+			}
+			if err := writeOrders(orders); err != nil {
+				log.Printf("Error updating order %s: %v", orderID, err)
+			}
+			return
+		}
+	}
+}
+
+// ==========================================
+// Admins & Permissions
+// ==========================================
+
+// parsePermissions turns "users,sales" or "all" into a validated list
+func parsePermissions(raw string) ([]string, error) {
+	return config.ParsePermissions(raw)
+}
+
+// logAdminAction appends an entry to the admin audit log
+func logAdminAction(adminID int64, action, detail string) {
+	if err := storage.LogAdminAction(AdminLogFile, adminID, action, detail); err != nil {
+		log.Printf("Failed to write admin log: %v", err)
+	}
+}
+
+// readAdminLog returns the last n audit entries, newest first
+func readAdminLog(n int) ([]storage.AdminAction, error) {
+	return storage.ReadAdminLog(AdminLogFile, n)
+}
+
+// handleAdminCommand handles the owner-only /addadmin, /deladmin, /admins and /adminlog commands
+func handleAdminCommand(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, config *BotConfig) {
+	chatID := msg.Chat.ID
+	args := strings.Fields(msg.CommandArguments())
+
+	switch msg.Command() {
+	case "admins":
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("👮 *Daftar Admin*\n\nOwner: `%d` (semua izin)\n", config.AdminID))
+		for _, a := range config.Admins {
+			sb.WriteString(fmt.Sprintf("• `%d` %s: %s\n", a.ID, a.Name, strings.Join(a.Permissions, ", ")))
+		}
+		sb.WriteString("\nIzin: " + strings.Join(allPermissions, ", "))
+		sendMessage(bot, chatID, sb.String())
+
+	case "adminlog":
+		n := 20
+		if len(args) > 0 {
+			if v, err := strconv.Atoi(args[0]); err == nil && v > 0 && v <= 100 {
+				n = v
+			}
+		}
+		entries, err := readAdminLog(n)
+		if err != nil {
+			replyError(bot, chatID, "Gagal membaca log admin: "+err.Error())
+			return
+		}
+		if len(entries) == 0 {
+			sendMessage(bot, chatID, "Log admin masih kosong.")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("📜 Log Admin\n\n")
+		for _, e := range entries {
+			sb.WriteString(fmt.Sprintf("%s | %d | %s %s\n", e.Time, e.AdminID, e.Action, e.Detail))
+		}
+		// Sent without Markdown, details may contain user input
+		bot.Send(tgbotapi.NewMessage(chatID, sb.String()))
+
+	case "addadmin":
+		if len(args) < 2 {
+			replyError(bot, chatID, "Format: /addadmin <user_id> <izin1,izin2|all> [nama]")
+			return
+		}
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || id == 0 || id == config.AdminID {
+			replyError(bot, chatID, "User ID tidak valid.")
+			return
+		}
+		perms, err := parsePermissions(args[1])
+		if err != nil {
+			replyError(bot, chatID, err.Error())
+			return
+		}
+		admin := Admin{ID: id, Name: strings.Join(args[2:], " "), Permissions: perms}
+
+		_, err = updateConfig(func(cfg *BotConfig) error {
+			for i, a := range cfg.Admins {
+				if a.ID == id {
+					cfg.Admins[i] = admin
+					return nil
+				}
+			}
+			cfg.Admins = append(cfg.Admins, admin)
+			return nil
+		})
+		if err != nil {
+			replyError(bot, chatID, err.Error())
+			return
+		}
+		logAdminAction(msg.From.ID, "admin_add", fmt.Sprintf("%d %s", id, strings.Join(perms, ",")))
+		sendMessage(bot, chatID, fmt.Sprintf("✅ Admin `%d` disimpan dengan izin: %s", id, strings.Join(perms, ", ")))
+
+	case "deladmin":
+		if len(args) < 1 {
+			replyError(bot, chatID, "Format: /deladmin <user_id>")
+			return
+		}
+		id, _ := strconv.ParseInt(args[0], 10, 64)
+
+		_, err := updateConfig(func(cfg *BotConfig) error {
+			var admins []Admin
+			for _, a := range cfg.Admins {
+				if a.ID != id {
+					admins = append(admins, a)
+				}
+			}
+			if len(admins) == len(cfg.Admins) {
+				return fmt.Errorf("Admin tidak ditemukan.")
+			}
+			cfg.Admins = admins
+			return nil
+		})
+		if err != nil {
+			replyError(bot, chatID, err.Error())
+			return
+		}
+		resetState(id)
+		logAdminAction(msg.From.ID, "admin_remove", strconv.FormatInt(id, 10))
+		sendMessage(bot, chatID, fmt.Sprintf("✅ Admin `%d` dihapus.", id))
+	}
+}
+
+// ==========================================
+// Admin Notifications
+// ==========================================
+
+var notifyTitles = map[string]string{
+	"invoice_created": "🧾 Invoice Dibuat",
+	"paid":            "💵 Pembayaran Diterima",
+	"fulfilled":       "✅ Order Selesai",
+	"failed":          "⚠️ Order Gagal Diproses",
+	"expired":         "⌛ Invoice Kedaluwarsa",
+	"cancelled":       "🚫 Invoice Dibatalkan",
+}
+
+func (c *BotConfig) notifies(event string) bool {
+	if len(c.NotifyEvents) == 0 {
+		return true
+	}
+	for _, e := range c.NotifyEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyOrder reports an order event to NotifyChatID (or AdminID)
+func notifyOrder(bot *tgbotapi.BotAPI, config *BotConfig, event string, userID int64, data map[string]string) {
+	if !config.notifies(event) {
+		return
+	}
+	target := config.NotifyChatID
+	if target == 0 {
+		target = config.AdminID
+	}
+
+	action := data["action"]
+	if action == "" {
+		action = "create"
+	}
+	text := fmt.Sprintf("%s\n\nOrder: %s\nUser: %s (%d)\nAksi: %s\nJumlah: Rp %s\nMetode: %s",
+		notifyTitles[event], data["order_id"], data["username"], userID, action, data["price"], data["method"])
+	if data["password"] != "" {
+		text += fmt.Sprintf("\nAkun: %s (%s hari)", data["password"], data["days"])
+	}
+	if data["voucher"] != "" {
+		text += "\nVoucher: " + data["voucher"]
+	}
+
+	if _, err := bot.Send(tgbotapi.NewMessage(target, text)); err != nil {
+		log.Printf("Error sending %s notification for %s: %v", event, data["order_id"], err)
+	}
+}
+
+// SalesSummary aggregates orders created in a time window. Revenue is money
+// received through QRIS; Sales is the value of fulfilled accounts.
+type SalesSummary struct {
+	Revenue      int
+	Sales        int
+	NewAccounts  int
+	Renewals     int
+	Topups       int
+	Invoices     int
+	PaidInvoices int
+}
+
+func summarizeOrders(orders []Order, from, to time.Time) SalesSummary {
+	var sum SalesSummary
+	for _, o := range orders {
+		created, err := time.ParseInLocation("2006-01-02 15:04:05", o.CreatedAt, time.Local)
+		if err != nil || created.Before(from) || !created.Before(to) {
+			continue
+		}
+
+		paid := o.Status == "paid" || o.Status == "fulfilled" || o.Status == "failed" || o.Status == "refunded"
+		if o.Method == "qris" {
+			sum.Invoices++
+			if paid {
+				sum.PaidInvoices++
+				sum.Revenue += o.Amount
+			}
+		}
+		if o.Status != "fulfilled" {
+			continue
+		}
+		switch o.Action {
+		case "topup":
+			sum.Topups++
+		case "renew":
+			sum.Renewals++
+			sum.Sales += o.Amount
+		default:
+			sum.NewAccounts++
+			sum.Sales += o.Amount
+		}
+	}
+	return sum
+}
+
+func formatSummary(title string, sum SalesSummary) string {
+	conversion := 0
+	if sum.Invoices > 0 {
+		conversion = sum.PaidInvoices * 100 / sum.Invoices
+	}
+	return fmt.Sprintf("*%s*\n• Pendapatan QRIS: Rp %d\n• Nilai Penjualan: Rp %d\n• Akun Baru: %d | Perpanjang: %d | Top Up: %d\n• Konversi Invoice: %d/%d (%d%%)\n",
+		title, sum.Revenue, sum.Sales, sum.NewAccounts, sum.Renewals, sum.Topups, sum.PaidInvoices, sum.Invoices, conversion)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func showSalesReport(bot *tgbotapi.BotAPI, chatID int64) {
+	orders, err := loadOrders()
+	if err != nil {
+		replyError(bot, chatID, "Gagal membaca data order.")
+		return
+	}
+
+	now := time.Now()
+	today := startOfDay(now)
+	var sb strings.Builder
+	sb.WriteString("📈 *Laporan Penjualan*\n\n")
+	sb.WriteString(formatSummary("Hari Ini", summarizeOrders(orders, today, now)))
+	sb.WriteString("\n" + formatSummary("7 Hari Terakhir", summarizeOrders(orders, today.AddDate(0, 0, -6), now)))
+	sb.WriteString("\n" + formatSummary("30 Hari Terakhir", summarizeOrders(orders, today.AddDate(0, 0, -29), now)))
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("📄 Export CSV", "admin_sales_csv")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Kembali", "menu_admin")),
+	)
+	sendAndTrack(bot, msg)
+}
+
+func exportOrdersCSV(bot *tgbotapi.BotAPI, chatID int64) {
+	orders, err := loadOrders()
+	if err != nil {
+		replyError(bot, chatID, "Gagal membaca data order.")
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	w.Write([]string{"id", "created_at", "paid_at", "user_id", "username", "action", "password", "plan_id", "days", "amount", "voucher", "method", "status"})
+	for _, o := range orders {
+		w.Write([]string{
+			o.ID, o.CreatedAt, o.PaidAt, strconv.FormatInt(o.UserID, 10), o.Username, o.Action, o.Password, o.PlanID,
+			strconv.Itoa(o.Days), strconv.Itoa(o.Amount), o.Voucher, o.Method, o.Status,
+		})
+	}
+	w.Flush()
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("zivpn-orders-%s.csv", time.Now().Format("20060102-150405")),
+		Bytes: buf.Bytes(),
+	})
+	doc.Caption = fmt.Sprintf("📄 Export %d order", len(orders))
+	if _, err := bot.Send(doc); err != nil {
+		log.Printf("Error sending orders CSV: %v", err)
+		replyError(bot, chatID, "Gagal mengirim file CSV.")
+	}
+}
+
+// nextReportTime returns the next occurrence of the "HH:MM" report time
+func nextReportTime(reportTime string, now time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", reportTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, nil
+}
+
+// startDailyReport sends the summary of the current day to AdminID at ReportTime
+func startDailyReport(bot *tgbotapi.BotAPI, config *BotConfig) {
+	reportTime := config.ReportTime
+	if reportTime == "" {
+		reportTime = "23:59"
+	}
+	if reportTime == "off" {
+		return
+	}
+
+	for {
+		next, err := nextReportTime(reportTime, time.Now())
+		if err != nil {
+			log.Printf("Invalid report_time %q, daily report disabled: %v", reportTime, err)
+			return
+		}
+		time.Sleep(time.Until(next))
+
+		orders, err := loadOrders()
+		if err != nil {
+			log.Printf("Error reading orders for daily report: %v", err)
+			continue
+		}
+		sum := summarizeOrders(orders, startOfDay(next), next.Add(time.Minute))
+		msg := tgbotapi.NewMessage(config.AdminID, "📊 "+formatSummary("Ringkasan Harian "+next.Format("2006-01-02"), sum))
+		msg.ParseMode = "Markdown"
+		if _, err := bot.Send(msg); err != nil {
+			log.Printf("Error sending daily report: %v", err)
+		}
+	}
+}
+
+// ==========================================
+// Pakasir API
+// ==========================================
+
+type PakasirPayment struct {
+	PaymentNumber string `json:"payment_number"`
+	ExpiredAt     string `json:"expired_at"`
+}
+
+func createPakasirTransaction(config *BotConfig, orderID string, amount int) (*PakasirPayment, error) {
+	url := fmt.Sprintf("https://app.pakasir.com/api/transactioncreate/qris")
+	payload := map[string]interface{}{
+		"project":  config.PakasirSlug,
+		"order_id": orderID,
+		"amount":   amount,
+		"api_key":  config.PakasirApiKey,
+	}
+
+	jsonPayload, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if paymentData, ok := result["payment"].(map[string]interface{}); ok {
+		return &PakasirPayment{
+			PaymentNumber: paymentData["payment_number"].(string),
+			ExpiredAt:     paymentData["expired_at"].(string),
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid response from Pakasir")
+}
+
+func cancelPakasirTransaction(config *BotConfig, orderID string, amountStr string) error {
+	amount, _ := strconv.Atoi(amountStr)
+	payload := map[string]interface{}{
+		"project":  config.PakasirSlug,
+		"order_id": orderID,
+		"amount":   amount,
+		"api_key":  config.PakasirApiKey,
+	}
+
+	jsonPayload, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "https://app.pakasir.com/api/transactioncancel", bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pakasir cancel returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func checkPakasirStatus(config *BotConfig, orderID string, amountStr string) (string, error) {
+	url := fmt.Sprintf("https://app.pakasir.com/api/transactiondetail?project=%s&amount=%s&order_id=%s&api_key=%s",
+		config.PakasirSlug, amountStr, orderID, config.PakasirApiKey)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if transaction, ok := result["transaction"].(map[string]interface{}); ok {
+		return transaction["status"].(string), nil
+	}
+	return "", fmt.Errorf("transaction not found")
+}
+
+// ==========================================
+// UI & Helpers (Simplified for Paid Bot)
+// ==========================================
+
+func showMainMenu(bot *tgbotapi.BotAPI, chatID int64, config *BotConfig) {
+	ipInfo, _ := getIpInfo()
+	domain := config.Domain
+	if domain == "" {
+		domain = "(Not Configured)"
+	}
+
+	plans, _ := loadPlans(config)
+	reseller := findReseller(chatID)
+	priceInfo := "-"
+	cheapest := -1
+	for _, p := range plans {
+		if price := p.priceFor(1, reseller); cheapest < 0 || price < cheapest {
+			cheapest = price
+		}
+	}
+	if cheapest >= 0 {
+		priceInfo = fmt.Sprintf("mulai Rp %d", cheapest)
+	}
+
+	msgText := fmt.Sprintf("```\n━━━━━━━━━━━━━━━━━━━━━\n    STORE ZIVPN UDP\n━━━━━━━━━━━━━━━━━━━━━\n • Domain   : %s\n • City     : %s\n • ISP      : %s\n • Harga    : %s\n━━━━━━━━━━━━━━━━━━━━━\n```\n👇 Silakan pilih menu dibawah ini:", domain, ipInfo.City, ipInfo.Isp, priceInfo)
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ParseMode = "Markdown"
+	
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🛒 Beli Akun Premium", "menu_create"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Perpanjang Akun", "menu_renew"),
+			tgbotapi.NewInlineKeyboardButtonData("👤 Akun Saya", "menu_my_accounts"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💰 Saldo", "menu_wallet"),
+		),
+	)
+
+	if reseller != nil {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏪 Panel Reseller", "menu_reseller"),
+		))
+	}
+
+	// Add Admin Panel for Admin
+	if config.IsAdmin(chatID) {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📊 System Info", "menu_info"),
+		))
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🛠️ Admin Panel", "menu_admin"),
+		))
+	}
+
+	msg.ReplyMarkup = keyboard
+	sendAndTrack(bot, msg)
+}
+
+func sendAccountInfo(bot *tgbotapi.BotAPI, chatID int64, acc apiclient.Account, config *BotConfig) {
+	ipInfo, _ := getIpInfo()
+	domain := config.Domain
+	if domain == "" {
+		domain = "(Not Configured)"
+	}
+
+	msg := fmt.Sprintf("```\n━━━━━━━━━━━━━━━━━━━━━\n  PREMIUM ACCOUNT\n━━━━━━━━━━━━━━━━━━━━━\nPassword   : %s\nCITY       : %s\nISP        : %s\nDomain     : %s\nExpired On : %s\n━━━━━━━━━━━━━━━━━━━━━\n```\nTerima kasih telah berlangganan!",
+		acc.Password, ipInfo.City, ipInfo.Isp, domain, acc.Expired,
+	)
+
+	reply := tgbotapi.NewMessage(chatID, msg)
+	reply.ParseMode = "Markdown"
+	deleteLastMessage(bot, chatID)
+	bot.Send(reply)
+	showMainMenu(bot, chatID, config)
+}
+
+// setupUI points ui at the menu message and error style of this bot
+func setupUI(bot *tgbotapi.BotAPI) {
+	ui = botui.New(bot, api, sessions, "zivpn-paid-bot")
+	ui.Show = func(msg tgbotapi.MessageConfig) { sendAndTrack(bot, msg) }
+	ui.DeleteLast = func(chatID int64) { deleteLastMessage(bot, chatID) }
+	ui.Error = func(chatID int64, text string) { replyError(bot, chatID, text) }
+	ui.AccountInfo = func(chatID int64, acc apiclient.Account) { sendAccountInfo(bot, chatID, acc, currentConfig()) }
+	ui.NewConfig = func() interface{} { return &BotConfig{} }
+	ui.BackLabel = "❌ Kembali"
+}
+
+func sendMessage(bot *tgbotapi.BotAPI, chatID int64, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	if _, inState := sessions.State(chatID); inState && !flows.Active(chatID) {
+		cancelKb := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Batal", "cancel")),
+		)
+		msg.ReplyMarkup = cancelKb
+	}
+	sendAndTrack(bot, msg)
+}
+
+func replyError(bot *tgbotapi.BotAPI, chatID int64, text string) {
+	sendMessage(bot, chatID, "❌ "+text)
+}
+
+func cancelOperation(bot *tgbotapi.BotAPI, chatID int64, userID int64, config *BotConfig) {
+	resetState(userID)
+	mutex.Lock()
+	// Conversation data without an invoice can go, pending orders keep polling
+	if data, ok := tempUserData[userID]; ok && data["order_id"] == "" {
+		delete(tempUserData, userID)
+	}
+	mutex.Unlock()
+	showMainMenu(bot, chatID, config)
+}
+
+func cancelOrder(bot *tgbotapi.BotAPI, chatID int64, userID int64, config *BotConfig) {
+	if voidOrder(bot, config, chatID, userID) {
+		showMainMenu(bot, chatID, config)
+	}
+}
+
+func sendAndTrack(bot *tgbotapi.BotAPI, msg tgbotapi.MessageConfig) {
+	deleteLastMessage(bot, msg.ChatID)
+	sentMsg, err := bot.Send(msg)
+	if err == nil {
+		sessions.SetLastMessage(msg.ChatID, sentMsg.MessageID)
+	}
+}
+
+func deleteLastMessage(bot *tgbotapi.BotAPI, chatID int64) {
+	if msgID, ok := sessions.TakeLastMessage(chatID); ok {
+		deleteMsg := tgbotapi.NewDeleteMessage(chatID, msgID)
+		bot.Request(deleteMsg)
+	}
+}
+
+func resetState(userID int64) {
+	sessions.Reset(userID)
+	// Don't delete tempUserData immediately if pending payment, but here we do for cancel
+}
+
+func validateNumber(bot *tgbotapi.BotAPI, chatID int64, text string, min, max int, fieldName string) (int, bool) {
+	val, err := strconv.Atoi(text)
+	if err != nil || val < min || val > max {
+		sendMessage(bot, chatID, fmt.Sprintf("❌ %s harus angka positif (%d-%d). Coba lagi:", fieldName, min, max))
+		return 0, false
+	}
+	return val, true
+}
+
+func systemInfo(bot *tgbotapi.BotAPI, chatID int64, config *BotConfig) {
+	info, err := api.Info()
+	if err != nil {
+		ui.APIError(chatID, "mengambil info", err)
+		return
+	}
+
+	ipInfo, _ := getIpInfo()
+	msg := fmt.Sprintf("```\n━━━━━━━━━━━━━━━━━━━━━\n    INFO ZIVPN UDP\n━━━━━━━━━━━━━━━━━━━━━\nDomain         : %s\nIP Public      : %s\nPort           : %s\nService        : %s\nCITY           : %s\nISP            : %s\n━━━━━━━━━━━━━━━━━━━━━\n```",
+		config.Domain, info.PublicIP, info.Port, info.Service, ipInfo.City, ipInfo.Isp)
+
+	reply := tgbotapi.NewMessage(chatID, msg)
+	reply.ParseMode = "Markdown"
+	deleteLastMessage(bot, chatID)
+	bot.Send(reply)
+	showMainMenu(bot, chatID, config)
+}
+
+func showBackupRestoreMenu(bot *tgbotapi.BotAPI, chatID int64, userID int64, config *BotConfig) {
+	msg := tgbotapi.NewMessage(chatID, "🛠️ *Admin Panel*\nSilakan pilih menu:")
+	msg.ParseMode = "Markdown"
+
+	// Only show the sections this admin has permission for
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if config.Can(userID, PermBackup) {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬇️ Backup Data", "menu_backup_action"),
+			tgbotapi.NewInlineKeyboardButtonData("⬆️ Restore Data", "menu_restore_action"),
+		))
+	}
+	if config.Can(userID, PermPrices) {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📦 Kelola Paket", "admin_plans"),
+			tgbotapi.NewInlineKeyboardButtonData("🎟 Kelola Voucher", "admin_vouchers"),
+		), tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏪 Kelola Reseller", "admin_resellers"),
+		))
+	}
+	if config.Can(userID, PermUsers) {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💰 Atur Saldo", "admin_wallet"),
+			tgbotapi.NewInlineKeyboardButtonData("📜 Audit Log", "admin_audit"),
+		))
+	}
+	if config.Can(userID, PermSales) {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📈 Laporan Penjualan", "admin_sales"),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("❌ Kembali", "cancel"),
+	))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	sendAndTrack(bot, msg)
+}
+
+func startRestore(bot *tgbotapi.BotAPI, chatID int64, userID int64) {
+	flows.Start("restore", userID, chatID, nil)
+}
+
+func saveConfig(cfg *BotConfig) error {
+	return storage.WriteJSON(BotConfigFile, cfg, 0644)
+}
+
+// currentConfig returns the active config. Do not modify it, use
+// updateConfig.
+func currentConfig() *BotConfig {
+	return botConfig.Load()
+}
+
+// updateConfig runs change on a copy of the active config, saves the copy
+// and makes it active. If change or saving fails, the old config stays.
+func updateConfig(change func(cfg *BotConfig) error) (*BotConfig, error) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	next := *currentConfig()
+	next.Admins = append([]Admin(nil), next.Admins...)
+	if err := change(&next); err != nil {
+		return nil, err
+	}
+	if err := saveConfig(&next); err != nil {
+		return nil, fmt.Errorf("Gagal menyimpan konfigurasi: %v", err)
+	}
+	botConfig.Store(&next)
+	return &next, nil
+}
+
+func loadConfig() (BotConfig, error) {
+	var cfg BotConfig
+	err := storage.ReadJSON(BotConfigFile, &cfg)
+
+	if cfg.Domain == "" {
+		cfg.Domain = config.LoadDomain()
+	}
+
+	return cfg, err
+}
+
+func getIpInfo() (IpInfo, error) {
+	resp, err := http.Get("http://ip-api.com/json/")
+	if err != nil {
+		return IpInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	var info IpInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return IpInfo{}, err
+	}
+	return info, nil
+}
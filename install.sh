@@ -155,10 +155,13 @@ WantedBy=multi-user.target
 EOF
 
 # Set up API service
+# The API and both bots are built from one module (cmd/ + internal/) in /etc/zivpn/src
 mkdir -p /etc/zivpn/api
-run_silent "Setting up API" "wget -q https://raw.githubusercontent.com/KjsZipvn/kjsbot/main/zivpn-api.go -O /etc/zivpn/api/zivpn-api.go && wget -q https://raw.githubusercontent.com/KjsZipvn/kjsbot/main/go.mod -O /etc/zivpn/api/go.mod"
-cd /etc/zivpn/api
-if go build -o zivpn-api zivpn-api.go &>/dev/null; then
+rm -rf /etc/zivpn/src && mkdir -p /etc/zivpn/src
+run_silent "Downloading Source" "wget -q https://github.com/KjsZipvn/kjsbot/archive/refs/heads/main.tar.gz -O /tmp/kjsbot.tar.gz && tar -xzf /tmp/kjsbot.tar.gz -C /etc/zivpn/src --strip-components=1 && rm -f /tmp/kjsbot.tar.gz"
+cd /etc/zivpn/src
+run_silent "Downloading Deps" "go mod download"
+if go build -o /etc/zivpn/api/zivpn-api ./cmd/zivpn-api &>/dev/null; then
   print_done "Compiling API"
 else
   print_fail "Compiling API"
@@ -203,7 +206,7 @@ if [[ -n "$bot_token" ]] && [[ -n "$admin_id" ]]; then
     read -p "Daily Price (IDR)   : " daily_price
     
     echo "{\"bot_token\": \"$bot_token\", \"admin_id\": $admin_id, \"mode\": \"public\", \"domain\": \"$domain\", \"pakasir_slug\": \"$pakasir_slug\", \"pakasir_api_key\": \"$pakasir_key\", \"daily_price\": $daily_price}" > /etc/zivpn/bot-config.json
-    bot_file="zivpn-paid-bot.go"
+    bot_cmd="zivpn-paid-bot"
   else
     # Bot Mode with default to private if no input
     read -p "Bot Mode (1 for private, 2 for public) [default: 1]: " bot_mode
@@ -217,14 +220,11 @@ if [[ -n "$bot_token" ]] && [[ -n "$admin_id" ]]; then
     fi
     
     echo "{\"bot_token\": \"$bot_token\", \"admin_id\": $admin_id, \"mode\": \"$bot_mode\", \"domain\": \"$domain\"}" > /etc/zivpn/bot-config.json
-    bot_file="zivpn-bot.go"
+    bot_cmd="zivpn-bot"
   fi
   
-  run_silent "Downloading Bot" "wget -q https://raw.githubusercontent.com/KjsZipvn/kjsbot/main/$bot_file -O /etc/zivpn/api/$bot_file"
-  cd /etc/zivpn/api
-  run_silent "Downloading Bot Deps" "go get github.com/go-telegram-bot-api/telegram-bot-api/v5"
-  
-  if go build -o zivpn-bot "$bot_file" &>/dev/null; then
+  cd /etc/zivpn/src
+  if go build -o /etc/zivpn/api/zivpn-bot "./cmd/$bot_cmd" &>/dev/null; then
     print_done "Compiling Bot"
     cat <<EOF > /etc/systemd/system/zivpn-bot.service
 [Unit]
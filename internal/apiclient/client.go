@@ -0,0 +1,546 @@
+// Package apiclient is a typed client for the ZiVPN management API (cmd/zivpn-api).
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Defaults used by New
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultRetries    = 2
+	DefaultRetryDelay = 500 * time.Millisecond
+)
+
+// Client talks to one API instance. The zero value is not usable, use New.
+type Client struct {
+	BaseURL    string // e.g. http://127.0.0.1:8080/api
+	APIKey     string
+	HTTP       *http.Client
+	Retries    int           // extra attempts after a failed one
+	RetryDelay time.Duration // doubled after every attempt
+
+	actor string
+}
+
+// New creates a client with the default timeout and retry policy
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    baseURL,
+		APIKey:     apiKey,
+		HTTP:       &http.Client{Timeout: DefaultTimeout},
+		Retries:    DefaultRetries,
+		RetryDelay: DefaultRetryDelay,
+	}
+}
+
+// As returns a copy of the client that acts for the Telegram user actorID.
+// The API records it in the audit log and, unless actorID is an admin with the
+// users permission, only lets it change accounts it owns.
+func (c *Client) As(actorID int64) *Client {
+	cp := *c
+	cp.actor = ""
+	if actorID != 0 {
+		cp.actor = fmt.Sprintf("tg:%d", actorID)
+	}
+	return &cp
+}
+
+// Response is the envelope returned by every API endpoint
+type Response struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Meta    *PageMeta       `json:"meta,omitempty"`
+}
+
+// PageMeta describes the page returned by list endpoints
+type PageMeta struct {
+	Total int `json:"total"`
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+	Pages int `json:"pages"`
+}
+
+// CreateUserRequest is the body of POST /user/create
+type CreateUserRequest struct {
+	Password string `json:"password"`
+	Days     int    `json:"days"`
+	OwnerID  int64  `json:"owner_id,omitempty"`
+	IpLimit  int    `json:"ip_limit,omitempty"`
+	QuotaGB  int    `json:"quota_gb,omitempty"`
+}
+
+// RenewUserRequest is the body of POST /user/renew. A non-zero OwnerID makes
+// the API refuse accounts owned by someone else.
+type RenewUserRequest struct {
+	Password string `json:"password"`
+	Days     int    `json:"days"`
+	OwnerID  int64  `json:"owner_id,omitempty"`
+}
+
+// DeleteUserRequest is the body of POST /user/delete
+type DeleteUserRequest struct {
+	Password string `json:"password"`
+	OwnerID  int64  `json:"owner_id,omitempty"`
+}
+
+// ChangePasswordRequest is the body of POST /user/password
+type ChangePasswordRequest struct {
+	Password    string `json:"password"`
+	NewPassword string `json:"new_password"`
+	OwnerID     int64  `json:"owner_id,omitempty"`
+}
+
+// Account is returned after creating, renewing or changing the password of an account
+type Account struct {
+	Password string `json:"password"`
+	Expired  string `json:"expired"`
+	Domain   string `json:"domain,omitempty"`
+}
+
+// User is one entry of GET /users
+type User struct {
+	Password string `json:"password"`
+	Expired  string `json:"expired"`
+	Status   string `json:"status"`
+	OwnerID  int64  `json:"owner_id"`
+	IpLimit  int    `json:"ip_limit"`
+	QuotaGB  int    `json:"quota_gb"`
+	Created  string `json:"created,omitempty"`
+}
+
+// ListOptions filters GET /users. Zero values match everything.
+type ListOptions struct {
+	OwnerID      int64  // 0 = all accounts
+	Status       string // "active", "locked" or "expired"
+	ExpiringDays int    // active accounts expiring within N days
+	CreatedFrom  string // YYYY-MM-DD
+	CreatedTo    string // YYYY-MM-DD
+	Search       string // substring of the password
+	Sort         string // password, expired, created, status or owner; "-" prefix for descending
+	Page         int    // 1-based, 0 = no pagination
+	Limit        int    // per page (API default 50 when Page is set)
+}
+
+func (o ListOptions) query() url.Values {
+	q := url.Values{}
+	for k, v := range map[string]string{"status": o.Status, "created_from": o.CreatedFrom, "created_to": o.CreatedTo, "q": o.Search, "sort": o.Sort} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	for k, v := range map[string]int64{"owner_id": o.OwnerID, "expiring_days": int64(o.ExpiringDays), "page": int64(o.Page), "limit": int64(o.Limit)} {
+		if v != 0 {
+			q.Set(k, strconv.FormatInt(v, 10))
+		}
+	}
+	return q
+}
+
+// UserPage is one page of GET /users
+type UserPage struct {
+	Users []User
+	PageMeta
+}
+
+// BulkOptions selects the accounts of a bulk operation
+type BulkOptions struct {
+	Filter ListOptions // same fields as ListUsers, paging and sort are ignored
+	All    bool        // lock/unlock everything; required when Filter is empty
+	DryRun bool        // only report the accounts that would change
+}
+
+func (o BulkOptions) query() url.Values {
+	q := o.Filter.query()
+	q.Del("page")
+	q.Del("limit")
+	q.Del("sort")
+	if o.All {
+		q.Set("all", "1")
+	}
+	if o.DryRun {
+		q.Set("dry_run", "1")
+	}
+	return q
+}
+
+// BulkCreateRequest is the body of POST /users/bulk/create. Passwords are
+// Prefix followed by a random suffix.
+type BulkCreateRequest struct {
+	Prefix  string `json:"prefix"`
+	Count   int    `json:"count"`
+	Days    int    `json:"days"`
+	OwnerID int64  `json:"owner_id,omitempty"`
+	IpLimit int    `json:"ip_limit,omitempty"`
+	QuotaGB int    `json:"quota_gb,omitempty"`
+}
+
+// CompensateRequest is the body of POST /users/bulk/compensate
+type CompensateRequest struct {
+	From   string `json:"from"`           // outage start, "YYYY-MM-DD HH:MM" or "YYYY-MM-DD"
+	To     string `json:"to"`             // outage end
+	Days   int    `json:"days,omitempty"` // 0 = outage length rounded up to days
+	Reason string `json:"reason,omitempty"`
+}
+
+// BulkResult is returned by the bulk endpoints
+type BulkResult struct {
+	Count    int    `json:"count"`
+	DryRun   bool   `json:"dry_run"`
+	Accounts []User `json:"accounts"`
+	Domain   string `json:"domain,omitempty"` // create only
+	Days     int    `json:"days,omitempty"`   // compensate only, days granted
+	Message  string `json:"-"`                // summary from the API, e.g. "3 akun dikunci"
+}
+
+// SystemInfo is returned by GET /info
+type SystemInfo struct {
+	Domain    string `json:"domain"`
+	PublicIP  string `json:"public_ip"`
+	PrivateIP string `json:"private_ip"`
+	Port      string `json:"port"`
+	Service   string `json:"service"`
+}
+
+// AuditEntry is one line of the API audit log
+type AuditEntry struct {
+	Time   string `json:"time"`
+	Actor  string `json:"actor"`
+	ApiKey string `json:"api_key,omitempty"`
+	Action string `json:"action"`
+	Target string `json:"target"`
+	Before *User  `json:"before,omitempty"`
+	After  *User  `json:"after,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// AuditFilter filters GET /audit. Empty fields are ignored.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	From   string // YYYY-MM-DD
+	To     string // YYYY-MM-DD
+	Limit  int
+}
+
+// CreateUser creates an account
+func (c *Client) CreateUser(req CreateUserRequest) (Account, error) {
+	var acc Account
+	err := c.do(http.MethodPost, "/user/create", req, &acc)
+	return acc, err
+}
+
+// RenewUser extends an account
+func (c *Client) RenewUser(req RenewUserRequest) (Account, error) {
+	var acc Account
+	err := c.do(http.MethodPost, "/user/renew", req, &acc)
+	return acc, err
+}
+
+// DeleteUser removes an account
+func (c *Client) DeleteUser(req DeleteUserRequest) error {
+	return c.do(http.MethodPost, "/user/delete", req, nil)
+}
+
+// ChangePassword replaces the password of an account, keeping its expiry
+func (c *Client) ChangePassword(req ChangePasswordRequest) (Account, error) {
+	var acc Account
+	err := c.do(http.MethodPost, "/user/password", req, &acc)
+	return acc, err
+}
+
+// ListUsers returns the accounts matching opts
+func (c *Client) ListUsers(opts ListOptions) ([]User, error) {
+	page, err := c.ListUsersPage(opts)
+	return page.Users, err
+}
+
+// ListUsersPage returns the accounts matching opts together with the page
+// position. Older APIs without pagination return everything as one page.
+func (c *Client) ListUsersPage(opts ListOptions) (UserPage, error) {
+	var resp Response
+	if err := c.request(http.MethodGet, withQuery("/users", opts.query()), nil, &resp); err != nil {
+		return UserPage{}, err
+	}
+	var page UserPage
+	if len(resp.Data) > 0 && string(resp.Data) != "null" {
+		if err := json.Unmarshal(resp.Data, &page.Users); err != nil {
+			return UserPage{}, fmt.Errorf("respon API tidak valid: %w", err)
+		}
+	}
+	if resp.Meta != nil {
+		page.PageMeta = *resp.Meta
+	} else {
+		page.PageMeta = PageMeta{Total: len(page.Users), Page: 1, Pages: 1}
+	}
+	return page, nil
+}
+
+// BulkCreate creates req.Count accounts at once
+func (c *Client) BulkCreate(req BulkCreateRequest, dryRun bool) (BulkResult, error) {
+	q := url.Values{}
+	if dryRun {
+		q.Set("dry_run", "1")
+	}
+	return c.bulk(withQuery("/users/bulk/create", q), req)
+}
+
+// BulkExtend adds days to the expiry of the selected accounts that are active
+func (c *Client) BulkExtend(opts BulkOptions, days int) (BulkResult, error) {
+	return c.bulk(withQuery("/users/bulk/extend", opts.query()), map[string]int{"days": days})
+}
+
+// BulkDelete removes the selected accounts that expired more than days ago
+func (c *Client) BulkDelete(opts BulkOptions, days int) (BulkResult, error) {
+	return c.bulk(withQuery("/users/bulk/delete", opts.query()), map[string]int{"days": days})
+}
+
+// BulkLock locks the selected accounts
+func (c *Client) BulkLock(opts BulkOptions) (BulkResult, error) {
+	return c.bulk(withQuery("/users/bulk/lock", opts.query()), nil)
+}
+
+// BulkUnlock unlocks the selected locked accounts that have not expired
+func (c *Client) BulkUnlock(opts BulkOptions) (BulkResult, error) {
+	return c.bulk(withQuery("/users/bulk/unlock", opts.query()), nil)
+}
+
+// Compensate extends the selected accounts that were active during an outage
+func (c *Client) Compensate(opts BulkOptions, req CompensateRequest) (BulkResult, error) {
+	return c.bulk(withQuery("/users/bulk/compensate", opts.query()), req)
+}
+
+func (c *Client) bulk(path string, body interface{}) (BulkResult, error) {
+	var resp Response
+	if err := c.request(http.MethodPost, path, body, &resp); err != nil {
+		return BulkResult{}, err
+	}
+	var result BulkResult
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return BulkResult{}, fmt.Errorf("respon API tidak valid: %w", err)
+	}
+	result.Message = resp.Message
+	return result, nil
+}
+
+// Export returns the selected accounts as a file, format is "csv" or "json"
+func (c *Client) Export(format string, filter ListOptions) ([]byte, error) {
+	q := filter.query()
+	q.Del("page")
+	q.Del("limit")
+	q.Set("format", format)
+	return c.fetch(http.MethodGet, withQuery("/users/export", q), nil)
+}
+
+// ImportOptions controls POST /users/import
+type ImportOptions struct {
+	Format string // "csv" or "json", "" = detect from the content
+	Policy string // for existing passwords: "skip" (default), "overwrite" or "merge"
+	DryRun bool   // only return the report
+}
+
+// ImportError is a rejected row of an import
+type ImportError struct {
+	Line     int    `json:"line"`
+	Password string `json:"password,omitempty"`
+	Error    string `json:"error"`
+}
+
+// ImportReport is returned by ImportUsers
+type ImportReport struct {
+	Total   int           `json:"total"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Skipped int           `json:"skipped"`
+	Invalid int           `json:"invalid"`
+	DryRun  bool          `json:"dry_run"`
+	Errors  []ImportError `json:"errors"` // at most 100, Invalid has the full count
+}
+
+// ImportUsers imports a CSV or JSON file produced by Export or another panel
+func (c *Client) ImportUsers(data []byte, opts ImportOptions) (ImportReport, error) {
+	q := url.Values{}
+	for k, v := range map[string]string{"format": opts.Format, "policy": opts.Policy} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if opts.DryRun {
+		q.Set("dry_run", "1")
+	}
+	var resp Response
+	var report ImportReport
+	if err := c.requestRaw(http.MethodPost, withQuery("/users/import", q), data, &resp); err != nil {
+		return report, err
+	}
+	if err := json.Unmarshal(resp.Data, &report); err != nil {
+		return report, fmt.Errorf("respon API tidak valid: %w", err)
+	}
+	return report, nil
+}
+
+// Info returns the server information
+func (c *Client) Info() (SystemInfo, error) {
+	var info SystemInfo
+	err := c.do(http.MethodGet, "/info", nil, &info)
+	return info, err
+}
+
+// Expire runs the expiration check and returns the API summary message
+func (c *Client) Expire() (string, error) {
+	var resp Response
+	err := c.request(http.MethodPost, "/cron/expire", nil, &resp)
+	return resp.Message, err
+}
+
+// Audit returns audit entries, newest first
+func (c *Client) Audit(f AuditFilter) ([]AuditEntry, error) {
+	q := url.Values{}
+	for k, v := range map[string]string{"actor": f.Actor, "action": f.Action, "target": f.Target, "from": f.From, "to": f.To} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	var entries []AuditEntry
+	err := c.do(http.MethodGet, withQuery("/audit", q), nil, &entries)
+	return entries, err
+}
+
+// RecordEvent adds an event that happened outside the API (e.g. a restore) to the audit log
+func (c *Client) RecordEvent(action, target, note string) error {
+	body := map[string]string{"action": action, "target": target, "note": note}
+	return c.do(http.MethodPost, "/audit", body, nil)
+}
+
+// do performs a request and decodes Response.Data into out (if not nil)
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var resp Response
+	if err := c.request(method, path, body, &resp); err != nil {
+		return err
+	}
+	if out == nil || len(resp.Data) == 0 || string(resp.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		return fmt.Errorf("respon API tidak valid: %w", err)
+	}
+	return nil
+}
+
+// request sends the request with retries and fills resp. Non-2xx statuses and
+// success=false become an *Error.
+func (c *Client) request(method, path string, body interface{}, resp *Response) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("gagal marshal payload: %w", err)
+		}
+	}
+
+	return c.requestRaw(method, path, payload, resp)
+}
+
+// requestRaw is request with a body that is already encoded
+func (c *Client) requestRaw(method, path string, payload []byte, resp *Response) error {
+	raw, err := c.fetch(method, path, payload)
+	if err != nil {
+		return err
+	}
+	*resp = Response{}
+	if err := json.Unmarshal(raw, resp); err != nil {
+		return fmt.Errorf("respon API tidak valid: %w", err)
+	}
+	if !resp.Success {
+		return &Error{Status: http.StatusOK, Message: resp.Message}
+	}
+	return nil
+}
+
+// fetch sends payload with retries and returns the body of a 2xx response.
+// Other statuses become an *Error carrying the API message.
+func (c *Client) fetch(method, path string, payload []byte) ([]byte, error) {
+	if c.APIKey == "" {
+		return nil, errors.New("API key belum dimuat")
+	}
+
+	delay := c.RetryDelay
+	var lastErr error
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		status, raw, err := c.send(method, path, payload)
+		if err != nil {
+			lastErr = fmt.Errorf("request API gagal: %w", err)
+			// A POST is only repeated when it never reached the server
+			if method == http.MethodGet || isDialError(err) {
+				continue
+			}
+			return nil, lastErr
+		}
+
+		if status < 200 || status > 299 {
+			var resp Response
+			apiErr := &Error{Status: status, Message: string(raw)}
+			if json.Unmarshal(raw, &resp) == nil && resp.Message != "" {
+				apiErr.Message = resp.Message
+			}
+			lastErr = apiErr
+			if method == http.MethodGet && status >= 500 {
+				continue
+			}
+			return nil, apiErr
+		}
+		return raw, nil
+	}
+	return nil, lastErr
+}
+
+func (c *Client) send(method, path string, payload []byte) (int, []byte, error) {
+	req, err := http.NewRequest(method, c.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", c.APIKey)
+	if c.actor != "" {
+		req.Header.Set("X-Actor", c.actor)
+	}
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+	raw, err := io.ReadAll(res.Body)
+	return res.StatusCode, raw, err
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func withQuery(path string, q url.Values) string {
+	if len(q) == 0 {
+		return path
+	}
+	return path + "?" + q.Encode()
+}
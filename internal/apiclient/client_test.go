@@ -0,0 +1,173 @@
+package apiclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client for srv that retries without waiting
+func newTestClient(url string) *Client {
+	c := New(url, "kunci")
+	c.RetryDelay = time.Millisecond
+	return c
+}
+
+// countingServer answers every request with status and body and counts them
+func countingServer(t *testing.T, status int, body string) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		call      func(c *Client) error
+		wantCalls int32
+	}{
+		{"GET retried on 5xx", http.StatusBadGateway, func(c *Client) error { _, err := c.Info(); return err }, 1 + DefaultRetries},
+		{"GET not retried on 4xx", http.StatusNotFound, func(c *Client) error { _, err := c.Info(); return err }, 1},
+		{"POST not retried on 5xx", http.StatusInternalServerError, func(c *Client) error {
+			_, err := c.CreateUser(CreateUserRequest{Password: "budi", Days: 30})
+			return err
+		}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := countingServer(t, tt.status, `{"success":false,"message":"gagal"}`)
+			if err := tt.call(newTestClient(srv.URL)); err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPostRetriedOnDialError(t *testing.T) {
+	// Reserve a port and close it again, so connecting to it is refused
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	var calls int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(w, `{"success":true,"message":"ok","data":{"password":"budi","expired":"2030-01-01"}}`)
+	}))
+	defer srv.Close()
+
+	c := newTestClient("http://" + addr)
+	c.RetryDelay = 50 * time.Millisecond
+	// The server comes up on the refused port before the first retry
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		srv.Listener = l
+		srv.Start()
+	}()
+
+	acc, err := c.CreateUser(CreateUserRequest{Password: "budi", Days: 30})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if acc.Password != "budi" || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("got %+v after %d calls", acc, calls)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrForbidden},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusInternalServerError, ErrServer},
+		{http.StatusServiceUnavailable, ErrServer},
+	}
+	sentinels := []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrConflict, ErrServer}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv, _ := countingServer(t, tt.status, `{"success":false,"message":"Akun tidak ditemukan"}`)
+			err := newTestClient(srv.URL).DeleteUser(DeleteUserRequest{Password: "budi"})
+			for _, s := range sentinels {
+				if got := errors.Is(err, s); got != (s == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", err, s, got)
+				}
+			}
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || apiErr.Status != tt.status || apiErr.Message != "Akun tidak ditemukan" {
+				t.Errorf("error = %#v", err)
+			}
+		})
+	}
+}
+
+func TestErrorWithoutMessageUsesBody(t *testing.T) {
+	srv, _ := countingServer(t, http.StatusBadRequest, "bad request")
+	err := newTestClient(srv.URL).DeleteUser(DeleteUserRequest{Password: "budi"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Message != "bad request" {
+		t.Errorf("error = %#v", err)
+	}
+}
+
+func TestUnsuccessfulResponseWithStatusOK(t *testing.T) {
+	srv, calls := countingServer(t, http.StatusOK, `{"success":false,"message":"Password sudah ada"}`)
+	_, err := newTestClient(srv.URL).CreateUser(CreateUserRequest{Password: "budi", Days: 30})
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Message != "Password sudah ada" {
+		t.Fatalf("error = %#v", err)
+	}
+	for _, s := range []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrConflict, ErrServer} {
+		if errors.Is(err, s) {
+			t.Errorf("errors.Is(%v, %v) = true", err, s)
+		}
+	}
+	if atomic.LoadInt32(calls) != 1 {
+		t.Errorf("calls = %d, want 1", *calls)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	var key, actor string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, actor = r.Header.Get("X-API-Key"), r.Header.Get("X-Actor")
+		fmt.Fprint(w, `{"success":true,"message":"ok"}`)
+	}))
+	defer srv.Close()
+
+	if err := newTestClient(srv.URL).As(42).RecordEvent("restore", "vps", ""); err != nil {
+		t.Fatal(err)
+	}
+	if key != "kunci" || actor != "tg:42" {
+		t.Errorf("X-API-Key = %q, X-Actor = %q", key, actor)
+	}
+	if err := New(srv.URL, "").RecordEvent("restore", "vps", ""); err == nil {
+		t.Error("expected an error without an API key")
+	}
+}
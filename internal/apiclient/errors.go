@@ -0,0 +1,43 @@
+package apiclient
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Sentinel errors for the statuses the API uses. Match them with errors.Is.
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrServer       = errors.New("server error")
+)
+
+// Error is a failed API call. Message is the API's own message, which is
+// already in Indonesian and can be shown to users.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is maps the HTTP status to the sentinel errors
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.Status == http.StatusForbidden
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrConflict:
+		return e.Status == http.StatusConflict
+	case ErrServer:
+		return e.Status >= 500
+	}
+	return false
+}
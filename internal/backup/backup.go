@@ -0,0 +1,274 @@
+// Package backup makes and opens the encrypted backups of /etc/zivpn sent by
+// the free and paid bots.
+//
+// A backup is a zip of the files in Files plus a manifest naming the schema
+// version, the source host and the SHA-256 of every file, encrypted with the
+// key in config.BackupKeyFile (see crypt.go).
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zivpn/internal/config"
+	"zivpn/internal/storage"
+)
+
+// SchemaVersion is the layout of the files in Files. Bump it when a file
+// changes in a way older code cannot read; restores of newer backups are
+// refused.
+const SchemaVersion = 1
+
+// Extension is the file extension of encrypted backups
+const Extension = ".zvbak"
+
+const (
+	manifestName = "manifest.json"
+	maxFileSize  = 50 << 20 // per file in the archive
+)
+
+// Files are the files under config.Dir put in a backup and the only ones a
+// restore writes
+var Files = append(fileNames(config.ServerConfig, config.UsersFile, config.DomainFile,
+	config.BotConfigFile, config.ApiKeyFile, config.ApiPortFile), stores...)
+
+// stores are the JSON stores of the paid bot. They are restored as they are,
+// only checked to be JSON.
+var stores = fileNames(config.PlansFile, config.VouchersFile, config.WalletsFile,
+	config.ResellersFile, config.OrdersFile)
+
+// fileNames returns the names of paths relative to config.Dir
+func fileNames(paths ...string) []string {
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = strings.TrimPrefix(p, config.Dir+"/")
+	}
+	return names
+}
+
+// isStore reports whether name is one of stores
+func isStore(name string) bool {
+	for _, s := range stores {
+		if name == s {
+			return true
+		}
+	}
+	return false
+}
+
+// FileEntry describes one file of the archive
+type FileEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest is stored as manifest.json inside the archive
+type Manifest struct {
+	Schema  int         `json:"schema"`
+	Created time.Time   `json:"created"`
+	Host    string      `json:"host"`
+	Source  string      `json:"source"` // the binary that made the backup
+	Files   []FileEntry `json:"files"`
+}
+
+// Archive is an opened and verified backup
+type Archive struct {
+	Manifest Manifest
+	Files    map[string][]byte
+}
+
+// FileName returns the name a backup made at t is sent under
+func FileName(host string, t time.Time) string {
+	return fmt.Sprintf("zivpn-backup-%s-%s%s", host, t.Format("20060102-150405"), Extension)
+}
+
+// Create reads Files from dir and returns them encrypted with key. Missing
+// files are left out.
+func Create(dir, source string, key *Key) ([]byte, Manifest, error) {
+	a, err := Snapshot(dir, source)
+	if err != nil {
+		return nil, Manifest{}, err
+	}
+	sealed, err := a.Seal(key)
+	return sealed, a.Manifest, err
+}
+
+// Snapshot reads Files from dir into an archive. Missing files are left out.
+func Snapshot(dir, source string) (*Archive, error) {
+	host, _ := os.Hostname()
+	a := &Archive{
+		Manifest: Manifest{Schema: SchemaVersion, Created: time.Now(), Host: host, Source: source},
+		Files:    make(map[string][]byte),
+	}
+	for _, name := range Files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		a.Manifest.Files = append(a.Manifest.Files, FileEntry{Name: name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+		a.Files[name] = data
+	}
+	return a, nil
+}
+
+// Seal zips the archive with its manifest and encrypts it with key
+func (a *Archive) Seal(key *Key) ([]byte, error) {
+	manifest, err := json.MarshalIndent(a.Manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	if err := writeZipFile(zw, manifestName, manifest); err != nil {
+		return nil, err
+	}
+	for _, f := range a.Manifest.Files {
+		if err := writeZipFile(zw, f.Name, a.Files[f.Name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return Encrypt(buf.Bytes(), key)
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Open decrypts a backup and checks it against its manifest. Backups that
+// were changed, made with another key, made by a newer schema or holding
+// files the manifest does not list are refused.
+func Open(data []byte, key *Key) (*Archive, error) {
+	plain, err := Decrypt(data, key)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(plain), int64(len(plain)))
+	if err != nil {
+		return nil, fmt.Errorf("isi backup bukan ZIP yang valid: %v", err)
+	}
+
+	contents := make(map[string][]byte)
+	for _, f := range zr.File {
+		if _, dup := contents[f.Name]; dup {
+			return nil, fmt.Errorf("file %s muncul dua kali di backup", f.Name)
+		}
+		if f.Name != manifestName && !allowed(f.Name) {
+			return nil, fmt.Errorf("backup berisi file yang tidak diizinkan: %s", f.Name)
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca %s dari backup: %v", f.Name, err)
+		}
+		contents[f.Name] = data
+	}
+
+	raw, ok := contents[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("backup tidak memiliki manifest, kemungkinan dibuat versi lama")
+	}
+	delete(contents, manifestName)
+	var m Manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("manifest backup tidak valid: %v", err)
+	}
+	if m.Schema < 1 || m.Schema > SchemaVersion {
+		return nil, fmt.Errorf("skema backup %d tidak didukung (versi ini: %d), perbarui bot terlebih dahulu", m.Schema, SchemaVersion)
+	}
+
+	listed := make(map[string]bool)
+	for _, f := range m.Files {
+		data, ok := contents[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("file %s ada di manifest tetapi tidak ada di backup", f.Name)
+		}
+		sum := sha256.Sum256(data)
+		if int64(len(data)) != f.Size || hex.EncodeToString(sum[:]) != f.SHA256 {
+			return nil, fmt.Errorf("hash file %s tidak cocok dengan manifest", f.Name)
+		}
+		listed[f.Name] = true
+	}
+	for name := range contents {
+		if !listed[name] {
+			return nil, fmt.Errorf("file %s tidak tercantum di manifest", name)
+		}
+	}
+	return &Archive{Manifest: m, Files: contents}, nil
+}
+
+func allowed(name string) bool {
+	for _, f := range Files {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxFileSize {
+		return nil, fmt.Errorf("ukuran melebihi %d MB", maxFileSize>>20)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxFileSize+1))
+	if err == nil && len(data) > maxFileSize {
+		err = fmt.Errorf("ukuran melebihi %d MB", maxFileSize>>20)
+	}
+	return data, err
+}
+
+// Restore writes the files of the archive into dir, keeping the mode of
+// files that already exist. New files get mode 0600.
+func (a *Archive) Restore(dir string) error {
+	for _, f := range a.Manifest.Files {
+		path := filepath.Join(dir, f.Name)
+		perm := os.FileMode(0600)
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+		if err := storage.WriteFile(path, a.Files[f.Name], perm); err != nil {
+			return fmt.Errorf("gagal menulis %s: %v", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// KeyNotice is sent with the first backup, after the key file was generated
+const KeyNotice = "🔑 Kunci backup baru dibuat di server: /etc/zivpn/backup.key\n\n" +
+	"Simpan salinan file ini di tempat aman. Tanpa kunci ini backup tidak bisa di-restore, " +
+	"dan untuk restore di server baru salin dulu file ini ke /etc/zivpn/backup.key."
+
+// Summary describes a manifest as plain text
+func Summary(m Manifest) string {
+	names := make([]string, len(m.Files))
+	for i, f := range m.Files {
+		names[i] = f.Name
+	}
+	return fmt.Sprintf("Host: %s\nDibuat: %s\nSkema: %d\nFile: %s",
+		m.Host, m.Created.Format("2006-01-02 15:04:05"), m.Schema, strings.Join(names, ", "))
+}
@@ -0,0 +1,175 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles creates a config directory holding the given files
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func mustKey(t *testing.T, s string) *Key {
+	t.Helper()
+	if s == "" {
+		k, err := GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	k, err := ParseKey(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"users.json": `[{"password":"budi123","expired":"2030-01-01","status":"active"}]`,
+		"apikey":     "rahasia",
+	})
+	tests := []struct {
+		name string
+		key  string // "" generates an X25519 identity
+	}{
+		{"passphrase", "passphrase-yang-cukup-panjang"},
+		{"x25519", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := mustKey(t, tt.key)
+			data, m, err := Create(dir, "test", key)
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if bytes.Contains(data, []byte("budi123")) || bytes.Contains(data, []byte("rahasia")) {
+				t.Fatal("backup contains plain text")
+			}
+			// The key must survive being written to and read from the key file
+			a, err := Open(data, mustKey(t, key.String()))
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if len(a.Manifest.Files) != 2 || a.Manifest.Schema != SchemaVersion || a.Manifest.Host != m.Host {
+				t.Errorf("manifest = %+v", a.Manifest)
+			}
+			if string(a.Files["apikey"]) != "rahasia" {
+				t.Errorf("apikey = %q", a.Files["apikey"])
+			}
+		})
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"users.json": `[]`})
+	pass := mustKey(t, "passphrase-yang-cukup-panjang")
+	ident := mustKey(t, "")
+	sealedPass, _, err := Create(dir, "test", pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealedIdent, _, err := Create(dir, "test", ident)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flip := func(data []byte, i int) []byte {
+		out := append([]byte(nil), data...)
+		out[i] ^= 1
+		return out
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		key  *Key
+		want error // nil: any error
+	}{
+		{"wrong passphrase", sealedPass, mustKey(t, "passphrase-yang-lain-sekali"), ErrTampered},
+		{"wrong x25519 key", sealedIdent, mustKey(t, ""), ErrWrongKey},
+		{"passphrase for x25519 backup", sealedIdent, pass, nil},
+		{"public key only", sealedIdent, mustKey(t, ident.Recipient()), ErrNoIdentity},
+		{"tampered ciphertext", flip(sealedPass, len(sealedPass)-1), pass, ErrTampered},
+		{"tampered header", flip(sealedPass, len(magic)+3), pass, ErrTampered},
+		{"tampered x25519 ciphertext", flip(sealedIdent, len(sealedIdent)-20), ident, ErrTampered},
+		{"plain zip", []byte("PK\x03\x04"), pass, ErrNotBackup},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(tt.data, tt.key)
+			if err == nil {
+				t.Fatal("Open succeeded")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// sealRaw encrypts a hand-made zip, so the manifest can lie about its files
+func sealRaw(t *testing.T, key *Key, m Manifest, files map[string]string) []byte {
+	t.Helper()
+	raw, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	writeZipFile(zw, manifestName, raw)
+	for name, content := range files {
+		writeZipFile(zw, name, []byte(content))
+	}
+	zw.Close()
+	data, err := Encrypt(buf.Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestOpenChecksManifest(t *testing.T) {
+	key := mustKey(t, "")
+	good := FileEntry{Name: "users.json", Size: 2, SHA256: "4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945"} // sha256("[]")
+	tests := []struct {
+		name    string
+		m       Manifest
+		files   map[string]string
+		wantErr string // "" means Open succeeds
+	}{
+		{"valid", Manifest{Schema: 1, Files: []FileEntry{good}}, map[string]string{"users.json": "[]"}, ""},
+		{"sha256 mismatch", Manifest{Schema: 1, Files: []FileEntry{good}}, map[string]string{"users.json": "{}"}, "hash file users.json"},
+		{"file missing", Manifest{Schema: 1, Files: []FileEntry{good}}, nil, "tidak ada di backup"},
+		{"file not listed", Manifest{Schema: 1}, map[string]string{"users.json": "[]"}, "tidak tercantum"},
+		{"file not allowed", Manifest{Schema: 1}, map[string]string{"../../etc/passwd": "x"}, "tidak diizinkan"},
+		{"newer schema", Manifest{Schema: SchemaVersion + 1}, nil, "tidak didukung"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.m.Created = time.Now()
+			_, err := Open(sealRaw(t, key, tt.m, tt.files), key)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Open: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// An encrypted backup is a header followed by an AES-256-GCM ciphertext of
+// the archive. The whole header is authenticated, so changing any byte of
+// the file makes decryption fail.
+//
+//	magic "ZIVPNBAK" | version | mode | mode fields | nonce (12)
+//
+// Mode fields are salt (16) and iterations (uint32) for a passphrase, or the
+// key ID (8) and ephemeral public key (32) for an X25519 key.
+const (
+	magic          = "ZIVPNBAK"
+	formatVersion  = 1
+	modePassphrase = 1
+	modeX25519     = 2
+
+	saltSize    = 16
+	keyIDSize   = 8
+	pbkdf2Iter  = 600000
+	maxIter     = 10000000
+	minPassLen  = 12
+	identityTag = "ZIVPN-IDENTITY-"
+	recipTag    = "ZIVPN-RECIPIENT-"
+	hkdfInfo    = "zivpn-backup x25519"
+)
+
+var (
+	ErrNotBackup   = errors.New("file bukan backup ZiVPN terenkripsi")
+	ErrVersion     = errors.New("format enkripsi backup tidak didukung oleh versi ini")
+	ErrWrongKey    = errors.New("backup dibuat dengan kunci lain")
+	ErrTampered    = errors.New("kunci salah atau file backup rusak/diubah")
+	ErrNoIdentity  = errors.New("kunci privat diperlukan untuk membuka backup, kunci di server hanya kunci publik")
+	errShortHeader = errors.New("header backup terpotong")
+)
+
+// Key encrypts and decrypts backups. It is either a passphrase, an X25519
+// identity (private key) or only an X25519 recipient (public key), which can
+// make backups but not open them.
+type Key struct {
+	passphrase string
+	identity   *ecdh.PrivateKey
+	recipient  *ecdh.PublicKey
+}
+
+// GenerateKey returns a new X25519 identity
+func GenerateKey() (*Key, error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{identity: priv, recipient: priv.PublicKey()}, nil
+}
+
+// ParseKey reads a key in the form written to the key file:
+// ZIVPN-IDENTITY-<base64>, ZIVPN-RECIPIENT-<base64> or a passphrase of at
+// least 12 characters
+func ParseKey(s string) (*Key, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, identityTag):
+		raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, identityTag))
+		if err != nil {
+			return nil, fmt.Errorf("kunci identitas tidak valid: %v", err)
+		}
+		priv, err := ecdh.X25519().NewPrivateKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("kunci identitas tidak valid: %v", err)
+		}
+		return &Key{identity: priv, recipient: priv.PublicKey()}, nil
+	case strings.HasPrefix(s, recipTag):
+		raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, recipTag))
+		if err != nil {
+			return nil, fmt.Errorf("kunci publik tidak valid: %v", err)
+		}
+		pub, err := ecdh.X25519().NewPublicKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("kunci publik tidak valid: %v", err)
+		}
+		return &Key{recipient: pub}, nil
+	}
+	if len([]rune(s)) < minPassLen {
+		return nil, fmt.Errorf("passphrase backup minimal %d karakter", minPassLen)
+	}
+	return &Key{passphrase: s}, nil
+}
+
+// LoadKey reads the key file
+func LoadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKey(string(data))
+}
+
+// LoadOrCreateKey reads the key file, generating an X25519 identity with
+// mode 0600 when it does not exist yet. created tells the caller to remind
+// the admin to keep a copy of the key.
+func LoadOrCreateKey(path string) (key *Key, created bool, err error) {
+	key, err = LoadKey(path)
+	if !os.IsNotExist(err) {
+		return key, false, err
+	}
+	if key, err = GenerateKey(); err != nil {
+		return nil, false, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, false, err
+	}
+	_, err = f.WriteString(key.String() + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, false, err
+	}
+	return key, true, nil
+}
+
+// String returns the key in the form ParseKey reads
+func (k *Key) String() string {
+	switch {
+	case k.passphrase != "":
+		return k.passphrase
+	case k.identity != nil:
+		return identityTag + base64.RawURLEncoding.EncodeToString(k.identity.Bytes())
+	}
+	return k.Recipient()
+}
+
+// Recipient returns the public half of an X25519 key, which can be put on
+// another server to make backups only this key can open. It is "" for a
+// passphrase.
+func (k *Key) Recipient() string {
+	if k.recipient == nil {
+		return ""
+	}
+	return recipTag + base64.RawURLEncoding.EncodeToString(k.recipient.Bytes())
+}
+
+// Describe names the kind of key for messages to the admin
+func (k *Key) Describe() string {
+	switch {
+	case k.passphrase != "":
+		return "passphrase"
+	case k.identity != nil:
+		return "X25519"
+	}
+	return "X25519 (kunci publik saja)"
+}
+
+// Encrypt seals plain with the key
+func Encrypt(plain []byte, k *Key) ([]byte, error) {
+	header := []byte(magic)
+	header = append(header, formatVersion)
+	var aesKey []byte
+
+	if k.passphrase != "" {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		header = append(header, modePassphrase)
+		header = append(header, salt...)
+		header = binary.BigEndian.AppendUint32(header, pbkdf2Iter)
+		aesKey = pbkdf2.Key([]byte(k.passphrase), salt, pbkdf2Iter, 32, sha256.New)
+	} else {
+		eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := eph.ECDH(k.recipient)
+		if err != nil {
+			return nil, err
+		}
+		header = append(header, modeX25519)
+		header = append(header, keyID(k.recipient)...)
+		header = append(header, eph.PublicKey().Bytes()...)
+		if aesKey, err = x25519Key(shared, eph.PublicKey(), k.recipient); err != nil {
+			return nil, err
+		}
+	}
+
+	gcm, err := newGCM(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header = append(header, nonce...)
+	return gcm.Seal(header, nonce, plain, header), nil
+}
+
+// Decrypt opens data sealed by Encrypt. Any change to the file is reported
+// as ErrTampered.
+func Decrypt(data []byte, k *Key) ([]byte, error) {
+	if len(data) < len(magic)+2 || string(data[:len(magic)]) != magic {
+		return nil, ErrNotBackup
+	}
+	if data[len(magic)] != formatVersion {
+		return nil, ErrVersion
+	}
+	mode := data[len(magic)+1]
+	rest := data[len(magic)+2:]
+	var aesKey []byte
+
+	switch mode {
+	case modePassphrase:
+		if len(rest) < saltSize+4 {
+			return nil, errShortHeader
+		}
+		if k.passphrase == "" {
+			return nil, fmt.Errorf("backup ini dikunci dengan passphrase, bukan kunci %s", k.Describe())
+		}
+		salt := rest[:saltSize]
+		iter := binary.BigEndian.Uint32(rest[saltSize:])
+		if iter == 0 || iter > maxIter {
+			return nil, ErrTampered
+		}
+		aesKey = pbkdf2.Key([]byte(k.passphrase), salt, int(iter), 32, sha256.New)
+		rest = rest[saltSize+4:]
+	case modeX25519:
+		if len(rest) < keyIDSize+32 {
+			return nil, errShortHeader
+		}
+		if k.identity == nil {
+			if k.passphrase != "" {
+				return nil, errors.New("backup ini dikunci dengan kunci X25519, bukan passphrase")
+			}
+			return nil, ErrNoIdentity
+		}
+		if !hmac.Equal(rest[:keyIDSize], keyID(k.recipient)) {
+			return nil, ErrWrongKey
+		}
+		eph, err := ecdh.X25519().NewPublicKey(rest[keyIDSize : keyIDSize+32])
+		if err != nil {
+			return nil, ErrTampered
+		}
+		shared, err := k.identity.ECDH(eph)
+		if err != nil {
+			return nil, ErrTampered
+		}
+		if aesKey, err = x25519Key(shared, eph, k.recipient); err != nil {
+			return nil, err
+		}
+		rest = rest[keyIDSize+32:]
+	default:
+		return nil, ErrVersion
+	}
+
+	gcm, err := newGCM(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, errShortHeader
+	}
+	nonce := rest[:gcm.NonceSize()]
+	header := data[:len(data)-len(rest)+gcm.NonceSize()]
+	plain, err := gcm.Open(nil, nonce, rest[gcm.NonceSize():], header)
+	if err != nil {
+		return nil, ErrTampered
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyID tells X25519 keys apart, so a backup opened with the wrong key gets
+// a clear error instead of ErrTampered
+func keyID(pub *ecdh.PublicKey) []byte {
+	sum := sha256.Sum256(pub.Bytes())
+	return sum[:keyIDSize]
+}
+
+// x25519Key derives the AES key of an X25519 backup from the shared secret
+func x25519Key(shared []byte, eph, recipient *ecdh.PublicKey) ([]byte, error) {
+	salt := append(eph.Bytes(), recipient.Bytes()...)
+	key := make([]byte, 32)
+	_, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(hkdfInfo)), key)
+	return key, err
+}
@@ -0,0 +1,463 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Services are restarted after a restore, in this order: the API first, so
+// it reads the new config before ZiVPN does. BotService is restarted last,
+// by restartBot.
+var Services = []string{"zivpn-api", "zivpn"}
+
+// BotService is the systemd service of the bot, free or paid
+const BotService = "zivpn-bot"
+
+// SnapshotKeep is the number of pre-restore snapshots kept
+const SnapshotKeep = 5
+
+const (
+	botConfigPrev     = "bot-config.json.prev" // in snapshotDir while the bot restarts
+	botRollbackMarker = "bot-rollback"         // left by restartBot after a rollback
+)
+
+// restartSettle is how long services get after a restart before they are
+// checked. A service that rejects its config exits well within it.
+const restartSettle = 3 * time.Second
+
+// diffListMax is the number of passwords listed per group in a diff
+const diffListMax = 10
+
+// serverConfig is the part of config.json the API and ZiVPN rely on
+type serverConfig struct {
+	Listen string `json:"listen"`
+	Auth   struct {
+		Mode   string   `json:"mode"`
+		Config []string `json:"config"`
+	} `json:"auth"`
+}
+
+// account mirrors an entry of users.json
+type account struct {
+	Password string `json:"password"`
+	Expired  string `json:"expired"`
+	Status   string `json:"status"`
+	OwnerID  int64  `json:"owner_id,omitempty"`
+	IpLimit  int    `json:"ip_limit,omitempty"`
+	QuotaGB  int    `json:"quota_gb,omitempty"`
+	Created  string `json:"created,omitempty"`
+}
+
+// botSettings are the bot-config.json fields both bots need to start
+type botSettings struct {
+	BotToken string `json:"bot_token"`
+	AdminID  int64  `json:"admin_id"`
+	Mode     string `json:"mode"`
+}
+
+// Validate checks the files of the archive before they are restored.
+// bot-config.json is also decoded into botConfig, the BotConfig of the
+// calling bot, so a field of the wrong type is caught before the bot
+// restarts with it.
+func (a *Archive) Validate(botConfig interface{}) error {
+	for _, name := range []string{"config.json", "users.json"} {
+		if _, ok := a.Files[name]; !ok {
+			return fmt.Errorf("backup tidak berisi %s", name)
+		}
+	}
+	for _, f := range a.Manifest.Files {
+		data := a.Files[f.Name]
+		var err error
+		switch f.Name {
+		case "config.json":
+			_, err = parseServerConfig(data)
+		case "users.json":
+			_, err = parseAccounts(data)
+		case "bot-config.json":
+			err = validateBotConfig(data, botConfig)
+		case "apikey":
+			if strings.TrimSpace(string(data)) == "" {
+				err = fmt.Errorf("API key kosong")
+			}
+		case "api_port":
+			port, perr := strconv.Atoi(strings.TrimSpace(string(data)))
+			if perr != nil || port <= 0 || port > 65535 {
+				err = fmt.Errorf("port tidak valid: %q", strings.TrimSpace(string(data)))
+			}
+		case "domain":
+			if strings.ContainsAny(strings.TrimSpace(string(data)), " \t\r\n") {
+				err = fmt.Errorf("domain tidak valid")
+			}
+		default:
+			if isStore(f.Name) && !json.Valid(data) {
+				err = fmt.Errorf("JSON tidak valid")
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func parseServerConfig(data []byte) (serverConfig, error) {
+	var c serverConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("JSON tidak valid: %v", err)
+	}
+	if c.Listen == "" {
+		return c, fmt.Errorf("listen kosong")
+	}
+	if c.Auth.Mode != "passwords" {
+		return c, fmt.Errorf("auth.mode harus passwords, bukan %q", c.Auth.Mode)
+	}
+	for _, p := range c.Auth.Config {
+		if p == "" {
+			return c, fmt.Errorf("auth.config berisi password kosong")
+		}
+	}
+	return c, nil
+}
+
+func parseAccounts(data []byte) (map[string]account, error) {
+	var list []account
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("JSON tidak valid: %v", err)
+	}
+	accounts := make(map[string]account, len(list))
+	for i, u := range list {
+		switch {
+		case u.Password == "":
+			return nil, fmt.Errorf("akun ke-%d tanpa password", i+1)
+		case accounts[u.Password].Password != "":
+			return nil, fmt.Errorf("password %s muncul dua kali", u.Password)
+		case u.Status != "" && u.Status != "active" && u.Status != "locked":
+			return nil, fmt.Errorf("status akun %s tidak dikenal: %s", u.Password, u.Status)
+		case u.IpLimit < 0 || u.QuotaGB < 0:
+			return nil, fmt.Errorf("batas akun %s negatif", u.Password)
+		}
+		if _, err := time.Parse("2006-01-02", u.Expired); err != nil {
+			return nil, fmt.Errorf("tanggal expired akun %s tidak valid: %q", u.Password, u.Expired)
+		}
+		if u.Created != "" {
+			if _, err := time.Parse("2006-01-02", u.Created); err != nil {
+				return nil, fmt.Errorf("tanggal dibuat akun %s tidak valid: %q", u.Password, u.Created)
+			}
+		}
+		accounts[u.Password] = u
+	}
+	return accounts, nil
+}
+
+func validateBotConfig(data []byte, botConfig interface{}) error {
+	if botConfig != nil {
+		if err := json.Unmarshal(data, botConfig); err != nil {
+			return fmt.Errorf("JSON tidak valid: %v", err)
+		}
+	}
+	var s botSettings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("JSON tidak valid: %v", err)
+	}
+	switch {
+	case s.BotToken == "":
+		return fmt.Errorf("bot_token kosong")
+	case s.AdminID == 0:
+		return fmt.Errorf("admin_id kosong")
+	case s.Mode != "" && s.Mode != "public" && s.Mode != "private":
+		return fmt.Errorf("mode harus public atau private")
+	}
+	return nil
+}
+
+// Diff is what a restore would change in the current files
+type Diff struct {
+	Added    []string // passwords only in the backup
+	Removed  []string // passwords only in the current users.json
+	Changed  []string // passwords whose expiry, status or limits differ
+	Settings []string // changed settings, without secret values
+	Kept     []string // current files the backup does not contain, left as they are
+}
+
+// Diff compares the archive with the files in dir
+func (a *Archive) Diff(dir string) Diff {
+	var d Diff
+	current := func(name string) ([]byte, bool) {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		return data, err == nil
+	}
+
+	if data, ok := a.Files["users.json"]; ok {
+		next, _ := parseAccounts(data)
+		cur := map[string]account{}
+		if old, ok := current("users.json"); ok {
+			if parsed, err := parseAccounts(old); err == nil {
+				cur = parsed
+			} else {
+				d.Settings = append(d.Settings, "users.json saat ini rusak dan akan diganti")
+			}
+		}
+		for p, u := range next {
+			old, ok := cur[p]
+			switch {
+			case !ok:
+				d.Added = append(d.Added, p)
+			case old.Expired != u.Expired || old.Status != u.Status || old.OwnerID != u.OwnerID ||
+				old.IpLimit != u.IpLimit || old.QuotaGB != u.QuotaGB:
+				d.Changed = append(d.Changed, p)
+			}
+		}
+		for p := range cur {
+			if _, ok := next[p]; !ok {
+				d.Removed = append(d.Removed, p)
+			}
+		}
+		sort.Strings(d.Added)
+		sort.Strings(d.Removed)
+		sort.Strings(d.Changed)
+	}
+
+	for _, name := range Files {
+		data, inBackup := a.Files[name]
+		old, exists := current(name)
+		switch {
+		case !inBackup:
+			if exists {
+				d.Kept = append(d.Kept, name)
+			}
+			continue
+		case bytes.Equal(old, data):
+			continue
+		}
+		switch name {
+		case "users.json":
+			// Already described by the account lists
+		case "config.json", "bot-config.json":
+			keys := changedKeys(old, data)
+			if len(keys) > 0 {
+				d.Settings = append(d.Settings, fmt.Sprintf("%s: %s", name, strings.Join(keys, ", ")))
+			}
+		case "apikey":
+			d.Settings = append(d.Settings, "apikey diganti")
+		default:
+			if isStore(name) {
+				d.Settings = append(d.Settings, name+" diganti")
+				continue
+			}
+			d.Settings = append(d.Settings, fmt.Sprintf("%s: %q → %q", name, strings.TrimSpace(string(old)), strings.TrimSpace(string(data))))
+		}
+	}
+	return d
+}
+
+// changedKeys lists the top-level keys of two JSON objects whose values
+// differ. auth.config of config.json is left out, the account lists cover it.
+func changedKeys(old, next []byte) []string {
+	var a, b map[string]interface{}
+	json.Unmarshal(old, &a)
+	json.Unmarshal(next, &b)
+	if auth, ok := a["auth"].(map[string]interface{}); ok {
+		delete(auth, "config")
+	}
+	if auth, ok := b["auth"].(map[string]interface{}); ok {
+		delete(auth, "config")
+	}
+	var keys []string
+	for k, v := range b {
+		if !reflect.DeepEqual(a[k], v) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String describes the diff as plain text
+func (d Diff) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("👥 Akun: %d baru, %d dihapus, %d berubah", len(d.Added), len(d.Removed), len(d.Changed)))
+	writeList(&sb, "Baru", d.Added)
+	writeList(&sb, "Dihapus", d.Removed)
+	writeList(&sb, "Berubah", d.Changed)
+	if len(d.Settings) == 0 {
+		sb.WriteString("\n\n⚙️ Pengaturan: tidak ada perubahan")
+	} else {
+		sb.WriteString("\n\n⚙️ Pengaturan yang berubah:")
+		for _, s := range d.Settings {
+			sb.WriteString("\n- " + s)
+		}
+	}
+	if len(d.Kept) > 0 {
+		sb.WriteString("\n\nTidak ada di backup, dibiarkan: " + strings.Join(d.Kept, ", "))
+	}
+	return sb.String()
+}
+
+func writeList(sb *strings.Builder, label string, passwords []string) {
+	if len(passwords) == 0 {
+		return
+	}
+	shown := passwords
+	if len(shown) > diffListMax {
+		shown = shown[:diffListMax]
+	}
+	sb.WriteString(fmt.Sprintf("\n%s: %s", label, strings.Join(shown, ", ")))
+	if len(passwords) > diffListMax {
+		sb.WriteString(fmt.Sprintf(" ... dan %d lainnya", len(passwords)-diffListMax))
+	}
+}
+
+// RollbackError reports a restore that was undone
+type RollbackError struct {
+	Err      error // why the restore was undone
+	Rollback error // nil when the previous files were written back
+	Recovery error // nil when the services run again after the rollback
+}
+
+func (e *RollbackError) Error() string {
+	switch {
+	case e.Rollback != nil:
+		return fmt.Sprintf("%v, dan rollback gagal: %v", e.Err, e.Rollback)
+	case e.Recovery != nil:
+		return fmt.Sprintf("%v. Data sudah dikembalikan ke kondisi sebelum restore, tetapi service masih gagal: %v", e.Err, e.Recovery)
+	}
+	return fmt.Sprintf("%v, data dikembalikan ke kondisi sebelum restore", e.Err)
+}
+
+// ErrBotNotRestarted is returned by Apply when the restore succeeded but the
+// bot could not be scheduled for a restart
+var ErrBotNotRestarted = errors.New("bot tidak bisa di-restart otomatis, restart zivpn-bot secara manual")
+
+// Apply replaces the files in dir with the archive. The current files are
+// first kept as an encrypted snapshot in snapshotDir, which has its own
+// rotation of SnapshotKeep copies so restores never push out scheduled
+// backups.
+//
+// preflight then checks the restored files the way the bot reads them at
+// startup, and Services are restarted and checked. If either fails the
+// snapshot is written back. Last, the bot is restarted by restartBot, which
+// also puts the previous bot-config.json back if the bot does not come up.
+// Apply returns the path of the snapshot.
+func (a *Archive) Apply(dir, snapshotDir string, key *Key, preflight func() error) (string, error) {
+	snap, err := Snapshot(dir, "pre-restore")
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat snapshot: %v", err)
+	}
+	sealed, err := snap.Seal(key)
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat snapshot: %v", err)
+	}
+	path, _, err := Store(snapshotDir, sealed, snap.Manifest, SnapshotKeep)
+	if err != nil {
+		return "", fmt.Errorf("gagal menyimpan snapshot: %v", err)
+	}
+
+	if err := a.Restore(dir); err != nil {
+		return path, &RollbackError{Err: err, Rollback: rollback(dir, snap)}
+	}
+	if preflight != nil {
+		if err := preflight(); err != nil {
+			return path, &RollbackError{Err: fmt.Errorf("bot tidak bisa berjalan dengan data backup: %v", err), Rollback: rollback(dir, snap)}
+		}
+	}
+	if err := Restart(Services...); err != nil {
+		rerr := &RollbackError{Err: fmt.Errorf("service gagal di-restart (%v)", err), Rollback: rollback(dir, snap)}
+		if rerr.Rollback == nil {
+			rerr.Recovery = Restart(Services...)
+		}
+		return path, rerr
+	}
+	if err := restartBot(dir, snapshotDir, snap); err != nil {
+		return path, fmt.Errorf("%w: %v", ErrBotNotRestarted, err)
+	}
+	return path, nil
+}
+
+// rollback writes the snapshot back and removes the files it did not have
+func rollback(dir string, snap *Archive) error {
+	if err := snap.Restore(dir); err != nil {
+		return err
+	}
+	for _, name := range Files {
+		if _, ok := snap.Files[name]; ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restart restarts the systemd services in order, then checks that every
+// one of them is still running
+func Restart(services ...string) error {
+	for _, service := range services {
+		if out, err := exec.Command("systemctl", "restart", service).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %v %s", service, err, strings.TrimSpace(string(out)))
+		}
+	}
+	time.Sleep(restartSettle)
+	for _, service := range services {
+		if err := exec.Command("systemctl", "is-active", "--quiet", service).Run(); err != nil {
+			return fmt.Errorf("%s tidak berjalan", service)
+		}
+	}
+	return nil
+}
+
+// restartBot restarts BotService from a transient systemd unit, because the
+// restart stops the bot calling it. When the bot is not running afterwards
+// the unit writes the previous bot-config.json back, restarts the bot again
+// and leaves a marker for BotRollbackNotice.
+func restartBot(dir, snapshotDir string, snap *Archive) error {
+	prev := filepath.Join(snapshotDir, botConfigPrev)
+	if old, ok := snap.Files["bot-config.json"]; ok {
+		if err := os.WriteFile(prev, old, 0600); err != nil {
+			return err
+		}
+	} else {
+		os.Remove(prev)
+	}
+	script := fmt.Sprintf(`sleep 2
+systemctl restart %[1]s
+sleep %[2]d
+if ! systemctl is-active --quiet %[1]s && [ -f %[3]q ]; then
+	cp %[3]q %[4]q && touch %[5]q
+	systemctl restart %[1]s
+fi
+rm -f %[3]q`, BotService, int(restartSettle/time.Second), prev, filepath.Join(dir, "bot-config.json"),
+		filepath.Join(snapshotDir, botRollbackMarker))
+	unit := fmt.Sprintf("%s-restore-%d", BotService, time.Now().Unix())
+	if out, err := exec.Command("systemd-run", "--unit", unit, "--collect", "/bin/sh", "-c", script).CombinedOutput(); err != nil {
+		os.Remove(prev)
+		return fmt.Errorf("%v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// BotRollbackNotice returns a message for the admin when the last restore
+// had its bot-config.json put back by restartBot, and "" otherwise. The bots
+// call it once at startup.
+func BotRollbackNotice(snapshotDir string) string {
+	if err := os.Remove(filepath.Join(snapshotDir, botRollbackMarker)); err != nil {
+		return ""
+	}
+	return "⚠️ Bot gagal berjalan dengan bot-config.json dari backup, jadi konfigurasi bot sebelumnya dikembalikan. " +
+		"File lain tetap dari backup."
+}
@@ -0,0 +1,154 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	validConfig = `{"listen":":5667","auth":{"mode":"passwords","config":["budi"]}}`
+	validUsers  = `[{"password":"budi","expired":"2030-01-01","status":"active"}]`
+)
+
+// archiveOf builds an archive holding files, as Open returns it
+func archiveOf(files map[string]string) *Archive {
+	a := &Archive{Files: make(map[string][]byte)}
+	for name, content := range files {
+		a.Manifest.Files = append(a.Manifest.Files, FileEntry{Name: name, Size: int64(len(content))})
+		a.Files[name] = []byte(content)
+	}
+	return a
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string // "" means valid
+	}{
+		{"minimal", map[string]string{"config.json": validConfig, "users.json": validUsers}, ""},
+		{"all files", map[string]string{"config.json": validConfig, "users.json": `[]`, "apikey": "rahasia\n", "api_port": "8080\n",
+			"domain": "vpn.example.com", "bot-config.json": `{"bot_token":"123:abc","admin_id":1,"mode":"private","max_days":30}`}, ""},
+		{"no users.json", map[string]string{"config.json": validConfig}, "tidak berisi users.json"},
+		{"config not json", map[string]string{"config.json": "{", "users.json": validUsers}, "config.json: JSON tidak valid"},
+		{"config without listen", map[string]string{"config.json": `{"auth":{"mode":"passwords"}}`, "users.json": validUsers}, "listen kosong"},
+		{"config wrong auth mode", map[string]string{"config.json": `{"listen":":1","auth":{"mode":"none"}}`, "users.json": validUsers}, "auth.mode"},
+		{"duplicate password", map[string]string{"config.json": validConfig,
+			"users.json": `[{"password":"budi","expired":"2030-01-01"},{"password":"budi","expired":"2030-01-01"}]`}, "dua kali"},
+		{"bad expiry", map[string]string{"config.json": validConfig, "users.json": `[{"password":"budi","expired":"besok"}]`}, "expired"},
+		{"unknown status", map[string]string{"config.json": validConfig, "users.json": `[{"password":"budi","expired":"2030-01-01","status":"expired"}]`}, "status"},
+		{"negative limit", map[string]string{"config.json": validConfig, "users.json": `[{"password":"budi","expired":"2030-01-01","ip_limit":-1}]`}, "negatif"},
+		{"empty apikey", map[string]string{"config.json": validConfig, "users.json": validUsers, "apikey": " \n"}, "API key kosong"},
+		{"bad port", map[string]string{"config.json": validConfig, "users.json": validUsers, "api_port": "70000"}, "port tidak valid"},
+		{"bad domain", map[string]string{"config.json": validConfig, "users.json": validUsers, "domain": "vpn example.com"}, "domain tidak valid"},
+		{"bot config without token", map[string]string{"config.json": validConfig, "users.json": validUsers,
+			"bot-config.json": `{"admin_id":1}`}, "bot_token kosong"},
+		{"bot config field of wrong type", map[string]string{"config.json": validConfig, "users.json": validUsers,
+			"bot-config.json": `{"bot_token":"123:abc","admin_id":1,"max_days":"30"}`}, "bot-config.json: JSON tidak valid"},
+		{"paid bot stores", map[string]string{"config.json": validConfig, "users.json": validUsers,
+			"plans.json": `[]`, "wallets.json": `{"1":{"balance":5000}}`}, ""},
+		{"store not json", map[string]string{"config.json": validConfig, "users.json": validUsers,
+			"orders.json": `[{"id":`}, "orders.json: JSON tidak valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var botConfig struct {
+				MaxDays int `json:"max_days"`
+			}
+			err := archiveOf(tt.files).Validate(&botConfig)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Validate: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	current := map[string]string{
+		"config.json": `{"listen":":5667","auth":{"mode":"passwords","config":["lama","tetap","ubah"]}}`,
+		"users.json": `[{"password":"lama","expired":"2030-01-01","status":"active"},` +
+			`{"password":"tetap","expired":"2030-01-01","status":"active"},` +
+			`{"password":"ubah","expired":"2030-01-01","status":"active"}]`,
+		"apikey":          "lama",
+		"domain":          "lama.example.com",
+		"bot-config.json": `{"bot_token":"123:abc","admin_id":1,"mode":"private"}`,
+		"orders.json":     `[]`,
+	}
+	tests := []struct {
+		name   string
+		backup map[string]string
+		want   Diff
+	}{
+		{"identical", current, Diff{}},
+		{"accounts", map[string]string{
+			"config.json": `{"listen":":5667","auth":{"mode":"passwords","config":["baru","tetap","ubah"]}}`,
+			"users.json": `[{"password":"baru","expired":"2030-01-01","status":"active"},` +
+				`{"password":"tetap","expired":"2030-01-01","status":"active"},` +
+				`{"password":"ubah","expired":"2031-01-01","status":"locked"}]`,
+		}, Diff{Added: []string{"baru"}, Removed: []string{"lama"}, Changed: []string{"ubah"},
+			Kept: []string{"domain", "bot-config.json", "apikey", "orders.json"}}},
+		{"settings", map[string]string{
+			"config.json":     `{"listen":":6000","auth":{"mode":"passwords","config":["lama","tetap","ubah"]}}`,
+			"users.json":      current["users.json"],
+			"apikey":          "baru",
+			"domain":          "baru.example.com\n",
+			"bot-config.json": `{"bot_token":"123:abc","admin_id":1,"mode":"public"}`,
+			"orders.json":     `[{"id":"ZIVPN-1"}]`,
+		}, Diff{Settings: []string{"config.json: listen", `domain: "lama.example.com" → "baru.example.com"`, "bot-config.json: mode", "apikey diganti", "orders.json diganti"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := archiveOf(tt.backup).Diff(writeFiles(t, current))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyRollsBackWhenPreflightFails(t *testing.T) {
+	src := writeFiles(t, map[string]string{
+		"config.json":     `{"listen":":5667","auth":{"mode":"passwords","config":["baru"]}}`,
+		"users.json":      `[{"password":"baru","expired":"2030-01-01","status":"active"}]`,
+		"bot-config.json": `{"bot_token":"baru","admin_id":1}`,
+	})
+	dir := writeFiles(t, map[string]string{
+		"config.json": `{"listen":":5667","auth":{"mode":"passwords","config":["lama"]}}`,
+		"users.json":  `[{"password":"lama","expired":"2030-01-01","status":"active"}]`,
+	})
+	snapshots := filepath.Join(t.TempDir(), "pre-restore")
+	key := mustKey(t, "")
+	a, err := Snapshot(src, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := a.Apply(dir, snapshots, key, func() error { return errors.New("token ditolak") })
+	var rerr *RollbackError
+	if !errors.As(err, &rerr) || rerr.Rollback != nil {
+		t.Fatalf("err = %v, want a successful rollback", err)
+	}
+	users, _ := os.ReadFile(filepath.Join(dir, "users.json"))
+	if string(users) != `[{"password":"lama","expired":"2030-01-01","status":"active"}]` {
+		t.Errorf("users.json not rolled back: %s", users)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bot-config.json")); !os.IsNotExist(err) {
+		t.Error("bot-config.json from the backup was not removed")
+	}
+	// The snapshot is a normal backup of the previous state
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := Open(data, key)
+	if err != nil || snap.Manifest.Source != "pre-restore" || len(snap.Files) != 2 {
+		t.Errorf("snapshot = %+v, %v", snap, err)
+	}
+}
@@ -0,0 +1,129 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"zivpn/internal/storage"
+)
+
+// DefaultKeep is the number of local copies kept when backup_keep is not set
+const DefaultKeep = 7
+
+// minInterval keeps a typo such as "1m" from filling the disk and the chat
+const minInterval = time.Hour
+
+// Schedule is the automatic backup part of bot-config.json. Both bots embed
+// it in their BotConfig, so the JSON keys stay at the top level.
+type Schedule struct {
+	BackupSchedule string `json:"backup_schedule"` // "HH:MM" daily, an interval such as "6h", or "off"
+	BackupKeep     int    `json:"backup_keep"`     // local copies kept, default DefaultKeep
+	BackupChatID   int64  `json:"backup_chat_id"`  // where backups are sent, 0 = AdminID
+}
+
+// Enabled reports whether automatic backups are configured
+func (s Schedule) Enabled() bool {
+	return s.BackupSchedule != "" && s.BackupSchedule != "off"
+}
+
+// Keep returns the number of local copies to keep
+func (s Schedule) Keep() int {
+	if s.BackupKeep <= 0 {
+		return DefaultKeep
+	}
+	return s.BackupKeep
+}
+
+// Next returns when the next backup is due. last is the time of the previous
+// backup (zero if none) and only matters for interval schedules, so a bot
+// restarted often still backs up on time.
+func (s Schedule) Next(last, now time.Time) (time.Time, error) {
+	if t, err := time.Parse("15:04", s.BackupSchedule); err == nil {
+		next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next, nil
+	}
+	interval, err := time.ParseDuration(s.BackupSchedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("backup_schedule %q harus HH:MM, interval seperti 6h, atau off", s.BackupSchedule)
+	}
+	if interval < minInterval {
+		return time.Time{}, fmt.Errorf("interval backup_schedule minimal %v", minInterval)
+	}
+	next := last.Add(interval)
+	if next.Before(now) {
+		next = now
+	}
+	return next, nil
+}
+
+// Store saves a backup in dir under FileName and deletes the oldest copies
+// beyond keep. It returns the path of the new file and how many were deleted.
+func Store(dir string, data []byte, m Manifest, keep int) (string, int, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", 0, err
+	}
+	path := filepath.Join(dir, FileName(m.Host, m.Created))
+	if err := storage.WriteFile(path, data, 0600); err != nil {
+		return "", 0, err
+	}
+
+	files, err := stored(dir)
+	if err != nil {
+		return path, 0, err
+	}
+	removed := 0
+	for len(files)-removed > keep {
+		if err := os.Remove(files[removed].path); err != nil {
+			return path, removed, err
+		}
+		removed++
+	}
+	return path, removed, nil
+}
+
+// Latest returns the time of the newest backup in dir, zero if there is none
+func Latest(dir string) time.Time {
+	files, err := stored(dir)
+	if err != nil || len(files) == 0 {
+		return time.Time{}
+	}
+	return files[len(files)-1].modTime
+}
+
+type storedFile struct {
+	path    string
+	modTime time.Time
+}
+
+// stored lists the backups in dir, oldest first
+func stored(dir string) ([]storedFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []storedFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), Extension) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, storedFile{filepath.Join(dir, e.Name()), info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].path < files[j].path
+		}
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files, nil
+}
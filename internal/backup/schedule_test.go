@@ -0,0 +1,128 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+	tests := []struct {
+		name     string
+		schedule string
+		last     time.Time
+		want     time.Time
+		wantErr  string
+	}{
+		{"daily later today", "23:00", time.Time{}, time.Date(2024, 5, 1, 23, 0, 0, 0, time.Local), ""},
+		{"daily already passed", "02:00", time.Time{}, time.Date(2024, 5, 2, 2, 0, 0, 0, time.Local), ""},
+		{"daily exactly now", "10:30", time.Time{}, time.Date(2024, 5, 2, 10, 30, 0, 0, time.Local), ""},
+		{"interval after last backup", "6h", now.Add(-2 * time.Hour), now.Add(4 * time.Hour), ""},
+		{"interval overdue", "6h", now.Add(-7 * time.Hour), now, ""},
+		{"interval without backups", "24h", time.Time{}, now, ""},
+		{"interval too short", "1m", time.Time{}, time.Time{}, "minimal"},
+		{"invalid", "tiap hari", time.Time{}, time.Time{}, "HH:MM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Schedule{BackupSchedule: tt.schedule}.Next(tt.last, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Next: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleEnabledAndKeep(t *testing.T) {
+	tests := []struct {
+		s       Schedule
+		enabled bool
+		keep    int
+	}{
+		{Schedule{}, false, DefaultKeep},
+		{Schedule{BackupSchedule: "off", BackupKeep: 3}, false, 3},
+		{Schedule{BackupSchedule: "03:00", BackupKeep: -1}, true, DefaultKeep},
+	}
+	for _, tt := range tests {
+		if got := tt.s.Enabled(); got != tt.enabled {
+			t.Errorf("%+v Enabled = %v", tt.s, got)
+		}
+		if got := tt.s.Keep(); got != tt.keep {
+			t.Errorf("%+v Keep = %d", tt.s, got)
+		}
+	}
+}
+
+func TestStoreRotates(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing int // older backups already in the directory
+		keep     int
+		removed  int
+	}{
+		{"below keep", 2, 5, 0},
+		{"at keep", 4, 5, 0},
+		{"above keep", 6, 3, 4},
+		{"keep one", 2, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+			for i := 0; i < tt.existing; i++ {
+				created := start.Add(time.Duration(i) * time.Hour)
+				path := filepath.Join(dir, FileName("host", created))
+				if err := os.WriteFile(path, []byte("lama"), 0600); err != nil {
+					t.Fatal(err)
+				}
+				os.Chtimes(path, created, created)
+			}
+			// Other files in the directory are never touched
+			os.WriteFile(filepath.Join(dir, "catatan.txt"), nil, 0600)
+
+			m := Manifest{Host: "host", Created: time.Now()}
+			path, removed, err := Store(dir, []byte("baru"), m, tt.keep)
+			if err != nil {
+				t.Fatalf("Store: %v", err)
+			}
+			if removed != tt.removed {
+				t.Errorf("removed = %d, want %d", removed, tt.removed)
+			}
+
+			files, _ := filepath.Glob(filepath.Join(dir, "*"+Extension))
+			sort.Strings(files)
+			if len(files) != tt.existing+1-tt.removed {
+				t.Fatalf("kept %v", files)
+			}
+			// The newest copies survive, the new backup included
+			if files[len(files)-1] != path {
+				t.Errorf("new backup %s was removed", path)
+			}
+			if tt.removed > 0 && tt.existing > tt.removed {
+				oldest := filepath.Join(dir, FileName("host", start.Add(time.Duration(tt.removed)*time.Hour)))
+				if files[0] != oldest {
+					t.Errorf("oldest kept = %s, want %s", files[0], oldest)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dir, "catatan.txt")); err != nil {
+				t.Error("unrelated file removed")
+			}
+			if got := Latest(dir); got.Before(start.Add(time.Duration(tt.existing) * time.Hour)) {
+				t.Errorf("Latest = %v, want the new backup", got)
+			}
+		})
+	}
+}
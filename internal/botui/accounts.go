@@ -0,0 +1,131 @@
+package botui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zivpn/internal/apiclient"
+)
+
+// FindOwnedAccount returns the account password of ownerID, if it is theirs
+func (ui *UI) FindOwnedAccount(ownerID int64, password string) (apiclient.User, bool) {
+	users, err := ui.API.ListUsers(apiclient.ListOptions{OwnerID: ownerID})
+	if err != nil {
+		log.Printf("Failed to list accounts of %d: %v", ownerID, err)
+		return apiclient.User{}, false
+	}
+	for _, u := range users {
+		if u.Password == password {
+			return u, true
+		}
+	}
+	return apiclient.User{}, false
+}
+
+// ShowMyAccounts lists the accounts of userID with a button for each
+func (ui *UI) ShowMyAccounts(chatID int64, userID int64) {
+	users, err := ui.API.ListUsers(apiclient.ListOptions{OwnerID: userID})
+	if err != nil {
+		ui.Error(chatID, "Gagal mengambil daftar akun: "+err.Error())
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("👤 *AKUN SAYA*\n\n")
+	if len(users) == 0 {
+		sb.WriteString("_Anda belum memiliki akun._")
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, u := range users {
+		sb.WriteString(fmt.Sprintf("╠═ `%s` • %s • exp `%s` (%d hari lagi)\n", u.Password, u.Status, u.Expired, RemainingDays(u.Expired)))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⚙️ "+u.Password, "acc:"+u.Password),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(ui.BackLabel, "cancel")))
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	ui.Show(msg)
+}
+
+// ShowAccountDetail shows one account of userID with its actions
+func (ui *UI) ShowAccountDetail(chatID int64, userID int64, password string) {
+	u, ok := ui.FindOwnedAccount(userID, password)
+	if !ok {
+		ui.Error(chatID, "Akun tidak ditemukan.")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⚙️ *DETAIL AKUN*\n\n"+
+		"╠═ 🔓 *Password* : `%s`\n"+
+		"╠═ 📶 *Status* : `%s`\n"+
+		"╠═ 📅 *Expired* : `%s`\n"+
+		"╠═ ⏳ *Sisa* : `%d hari`",
+		u.Password, u.Status, u.Expired, RemainingDays(u.Expired)))
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Perpanjang", "select_renew:"+u.Password),
+			tgbotapi.NewInlineKeyboardButtonData("🔑 Ganti Password", "acc_rotate:"+u.Password),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📡 Info Koneksi", "acc_info:"+u.Password),
+		),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(ui.BackLabel, "menu_my_accounts")),
+	)
+	ui.Show(msg)
+}
+
+// ShowConnectionInfo sends the connection details of an account of userID
+func (ui *UI) ShowConnectionInfo(chatID int64, userID int64, password string) {
+	u, ok := ui.FindOwnedAccount(userID, password)
+	if !ok {
+		ui.Error(chatID, "Akun tidak ditemukan.")
+		return
+	}
+	ui.AccountInfo(chatID, apiclient.Account{Password: u.Password, Expired: u.Expired})
+}
+
+// ConfirmRotatePassword asks before replacing the password of an account
+func (ui *UI) ConfirmRotatePassword(chatID int64, userID int64, password string) {
+	if _, ok := ui.FindOwnedAccount(userID, password); !ok {
+		ui.Error(chatID, "Akun tidak ditemukan.")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🔑 Ganti password `%s` dengan password acak baru?\nPerangkat yang memakai password lama akan terputus.", password))
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Ya, Ganti", "acc_rotate_ok:"+password),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Batal", "acc:"+password),
+		),
+	)
+	ui.Show(msg)
+}
+
+// RotatePassword replaces the password of an account of userID with a
+// random one
+func (ui *UI) RotatePassword(chatID int64, userID int64, password string) {
+	// Callback data comes from the client, so ownership is checked again here
+	if _, ok := ui.FindOwnedAccount(userID, password); !ok {
+		ui.Error(chatID, "Akun tidak ditemukan.")
+		return
+	}
+
+	acc, err := ui.API.As(userID).ChangePassword(apiclient.ChangePasswordRequest{
+		Password:    password,
+		NewPassword: GeneratePassword(),
+		OwnerID:     userID,
+	})
+	if err != nil {
+		ui.APIError(chatID, "mengganti password", err)
+		return
+	}
+	ui.AccountInfo(chatID, acc)
+}
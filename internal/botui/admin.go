@@ -0,0 +1,200 @@
+package botui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zivpn/internal/apiclient"
+	"zivpn/internal/bulk"
+	"zivpn/internal/telegram"
+)
+
+// ShowAuditLog shows the latest account changes recorded by the API,
+// optionally only those for one account
+func (ui *UI) ShowAuditLog(chatID int64, target string) {
+	entries, err := ui.API.Audit(apiclient.AuditFilter{Target: strings.TrimSpace(target), Limit: 15})
+	if err != nil {
+		ui.APIError(chatID, "membaca audit log", err)
+		return
+	}
+	if len(entries) == 0 {
+		ui.send(chatID, "Audit log kosong.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📜 AUDIT LOG (terbaru)\n\n")
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("%s | %s | %s %s", e.Time, e.Actor, e.Action, e.Target))
+		if e.Before != nil && e.After != nil && e.Before.Expired != e.After.Expired {
+			sb.WriteString(fmt.Sprintf(" | exp %s → %s", e.Before.Expired, e.After.Expired))
+		}
+		if e.Note != "" {
+			sb.WriteString(" | " + e.Note)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\nFilter per akun: /audit <password>")
+	// Plain text, targets and notes are user input
+	ui.send(chatID, sb.String())
+}
+
+// HandleBulkCommand runs /bulkcreate, /bulkextend, /bulkdelete, /bulklock
+// and /bulkunlock. Except for /bulkcreate a dry run is shown first and the
+// command only runs once it is confirmed.
+func (ui *UI) HandleBulkCommand(msg *tgbotapi.Message) {
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	cmd, err := bulk.Parse(msg.Command(), msg.CommandArguments())
+	if err != nil {
+		ui.send(chatID, "❌ "+err.Error()+"\n\n"+bulk.Usage)
+		return
+	}
+	if !cmd.NeedsConfirm() {
+		ui.runBulk(chatID, userID, cmd)
+		return
+	}
+
+	preview, err := cmd.Run(ui.API.As(userID), true)
+	if err != nil {
+		ui.APIError(chatID, "menjalankan simulasi", err)
+		return
+	}
+	if preview.Count == 0 {
+		ui.send(chatID, "ℹ️ Tidak ada akun yang cocok.")
+		return
+	}
+
+	// The command is kept in the session until the button is pressed
+	ui.Store.Set(userID, chatID, "bulk", msg.Command()+" "+msg.CommandArguments())
+	ui.confirm(chatID, "⚠️ KONFIRMASI PERINTAH MASSAL\n\n"+bulk.Summary(preview, 20), "✅ Jalankan", CallbackBulk)
+}
+
+// ConfirmBulk runs the command previewed by HandleBulkCommand
+func (ui *UI) ConfirmBulk(chatID int64, userID int64) {
+	name, args, _ := strings.Cut(ui.Store.Get(userID, "bulk"), " ")
+	ui.Store.Reset(userID)
+	cmd, err := bulk.Parse(name, args)
+	if err != nil {
+		ui.Error(chatID, "Konfirmasi sudah tidak berlaku. Kirim ulang perintahnya.")
+		return
+	}
+	ui.runBulk(chatID, userID, cmd)
+}
+
+func (ui *UI) runBulk(chatID int64, userID int64, cmd bulk.Command) {
+	res, err := cmd.Run(ui.API.As(userID), false)
+	if err != nil {
+		ui.APIError(chatID, "menjalankan perintah massal", err)
+		return
+	}
+	logAdmin(userID, cmd.Name, fmt.Sprintf("%d akun", res.Count))
+	ui.DeleteLast(chatID)
+	// Plain text, passwords may contain underscores
+	ui.send(chatID, "✅ "+bulk.Summary(res, 100))
+	if cmd.Notify && res.Count > 0 {
+		go ui.notifyOwners(chatID, bulk.CompensationNotices(res))
+	}
+}
+
+// notifyOwners messages every account owner, then reports the outcome to the admin
+func (ui *UI) notifyOwners(chatID int64, notices map[int64]string) {
+	sent, failed := 0, 0
+	for owner, text := range notices {
+		if _, err := ui.Bot.Send(tgbotapi.NewMessage(owner, text)); err != nil {
+			log.Printf("Failed to notify owner %d: %v", owner, err)
+			failed++
+		} else {
+			sent++
+		}
+		// Stay below the Telegram send limit (about 30 messages per second)
+		time.Sleep(50 * time.Millisecond)
+	}
+	ui.send(chatID, fmt.Sprintf("📨 Notifikasi kompensasi: %d terkirim, %d gagal.", sent, failed))
+}
+
+// ExportUsers sends the user database as a CSV or JSON file
+func (ui *UI) ExportUsers(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	format, filter, err := bulk.ParseExport(msg.CommandArguments())
+	if err != nil {
+		ui.send(chatID, "❌ "+err.Error()+"\n\n"+bulk.ExportUsage)
+		return
+	}
+	data, err := ui.API.Export(format, filter)
+	if err != nil {
+		ui.APIError(chatID, "mengekspor user", err)
+		return
+	}
+
+	logAdmin(msg.From.ID, "export", strings.TrimSpace(msg.CommandArguments()))
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("zivpn-users-%s.%s", time.Now().Format("20060102-150405"), format),
+		Bytes: data,
+	})
+	doc.Caption = "📄 Export user. Kirim file ini kembali ke bot untuk mengimport."
+	if _, err := ui.Bot.Send(doc); err != nil {
+		log.Printf("Failed to send user export: %v", err)
+		ui.Error(chatID, "Gagal mengirim file export.")
+	}
+}
+
+// PreviewImport dry-runs a CSV/JSON file sent by an admin and asks for
+// confirmation. The caption picks the policy for existing passwords: skip
+// (default), overwrite or merge.
+func (ui *UI) PreviewImport(msg *tgbotapi.Message, format string) {
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	policy, err := bulk.ImportPolicy(msg.Caption)
+	if err != nil {
+		ui.Error(chatID, err.Error())
+		return
+	}
+	data, err := telegram.DownloadFile(ui.Bot, msg.Document.FileID)
+	if err != nil {
+		ui.Error(chatID, err.Error())
+		return
+	}
+	report, err := ui.API.As(userID).ImportUsers(data, apiclient.ImportOptions{Format: format, Policy: policy, DryRun: true})
+	if err != nil {
+		ui.APIError(chatID, "memeriksa file import", err)
+		return
+	}
+
+	text := "📥 SIMULASI IMPORT\n\n" + bulk.ImportSummary(report, policy)
+	if report.Created+report.Updated == 0 {
+		ui.send(chatID, text+"\n\nTidak ada perubahan untuk diimport.")
+		return
+	}
+
+	// The file is downloaded again on confirmation, so only its ID is kept
+	ui.Store.Set(userID, chatID, "import", strings.Join([]string{policy, format, msg.Document.FileID}, " "))
+	ui.confirm(chatID, text, "✅ Import", CallbackImport)
+}
+
+// ConfirmImport runs the import previewed by PreviewImport
+func (ui *UI) ConfirmImport(chatID int64, userID int64) {
+	fields := strings.Fields(ui.Store.Get(userID, "import"))
+	ui.Store.Reset(userID)
+	if len(fields) != 3 {
+		ui.Error(chatID, "Konfirmasi sudah tidak berlaku. Kirim ulang filenya.")
+		return
+	}
+	policy, format, fileID := fields[0], fields[1], fields[2]
+
+	data, err := telegram.DownloadFile(ui.Bot, fileID)
+	if err != nil {
+		ui.Error(chatID, err.Error())
+		return
+	}
+	report, err := ui.API.As(userID).ImportUsers(data, apiclient.ImportOptions{Format: format, Policy: policy})
+	if err != nil {
+		ui.APIError(chatID, "mengimport user", err)
+		return
+	}
+	logAdmin(userID, "import", fmt.Sprintf("%s: %d dibuat, %d diperbarui", policy, report.Created, report.Updated))
+	ui.DeleteLast(chatID)
+	ui.send(chatID, "✅ IMPORT SELESAI\n\n"+bulk.ImportSummary(report, policy))
+}
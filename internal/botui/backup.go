@@ -0,0 +1,227 @@
+package botui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zivpn/internal/backup"
+	"zivpn/internal/config"
+	"zivpn/internal/telegram"
+)
+
+// SendBackup sends an encrypted backup of the ZiVPN config, the users and
+// the bot config. The key is generated on the first backup.
+func (ui *UI) SendBackup(chatID int64) {
+	ui.DeleteLast(chatID)
+	done := ui.status(chatID, "⏳ Sedang membuat backup. Mohon tunggu...")
+
+	key, created, err := backup.LoadOrCreateKey(config.BackupKeyFile)
+	if err != nil {
+		log.Printf("Failed to load backup key: %v", err)
+		done()
+		ui.Error(chatID, "Kunci backup tidak valid: "+err.Error())
+		return
+	}
+	data, manifest, err := backup.Create(config.Dir, ui.Source, key)
+	if err != nil {
+		log.Printf("Failed to create backup: %v", err)
+		done()
+		ui.Error(chatID, "Gagal membuat file backup.")
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: backup.FileName(manifest.Host, manifest.Created), Bytes: data})
+	doc.Caption = "✅ Backup Data ZiVPN (terenkripsi " + key.Describe() + ")\n\n" + backup.Summary(manifest)
+	done()
+	if _, err := ui.Bot.Send(doc); err != nil {
+		log.Printf("Failed to send backup: %v", err)
+		ui.Error(chatID, "Gagal mengirim file backup ke Telegram.")
+		return
+	}
+	if created {
+		ui.send(chatID, backup.KeyNotice)
+	}
+}
+
+// PreviewRestore opens a backup sent by an admin, validates it and shows
+// what it would change. Nothing is written before the admin confirms.
+func (ui *UI) PreviewRestore(msg *tgbotapi.Message) {
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	ui.Store.Reset(userID)
+	done := ui.status(chatID, "⏳ Sedang memproses file restore. Mohon tunggu...")
+
+	archive, err := ui.openRestoreFile(msg.Document.FileID)
+	done()
+	if err != nil {
+		log.Printf("Restore of %s refused: %v", msg.Document.FileName, err)
+		ui.Error(chatID, "Restore ditolak: "+err.Error())
+		return
+	}
+
+	// The file is downloaded again on confirmation, so only its ID is kept
+	ui.Store.Set(userID, chatID, "restore", msg.Document.FileID)
+	ui.confirm(chatID, "📋 PRATINJAU RESTORE\n\n"+backup.Summary(archive.Manifest)+"\n\n"+
+		archive.Diff(config.Dir).String()+"\n\n"+
+		"Data saat ini disimpan sebagai snapshot di "+config.SnapshotDir+" sebelum ditimpa, dan dikembalikan otomatis jika service gagal di-restart.",
+		"✅ Restore", CallbackRestore)
+}
+
+// openRestoreFile downloads, decrypts and validates a backup
+func (ui *UI) openRestoreFile(fileID string) (*backup.Archive, error) {
+	key, err := backup.LoadKey(config.BackupKeyFile)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("kunci backup belum ada di server. Salin file backup.key dari server asal ke %s terlebih dahulu", config.BackupKeyFile)
+	} else if err != nil {
+		return nil, fmt.Errorf("kunci backup tidak valid: %v", err)
+	}
+	body, err := telegram.DownloadFile(ui.Bot, fileID)
+	if err != nil {
+		return nil, err
+	}
+	// Decrypt and check every file against the manifest before touching the live data
+	archive, err := backup.Open(body, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := archive.Validate(ui.NewConfig()); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// ConfirmRestore runs the restore previewed by PreviewRestore
+func (ui *UI) ConfirmRestore(chatID int64, userID int64) {
+	fileID := ui.Store.Get(userID, "restore")
+	ui.Store.Reset(userID)
+	if fileID == "" {
+		ui.Error(chatID, "Konfirmasi sudah tidak berlaku. Kirim ulang file backup.")
+		return
+	}
+	ui.DeleteLast(chatID)
+	done := ui.status(chatID, "⏳ Sedang me-restore data dan me-restart Service ZiVPN dan API...")
+
+	archive, err := ui.openRestoreFile(fileID)
+	if err != nil {
+		done()
+		ui.Error(chatID, "Restore ditolak: "+err.Error())
+		return
+	}
+	key, err := backup.LoadKey(config.BackupKeyFile)
+	if err != nil {
+		done()
+		ui.Error(chatID, "Kunci backup tidak valid: "+err.Error())
+		return
+	}
+
+	// Recorded before the API restarts
+	logAdmin(userID, "restore", archive.Manifest.Host+" "+archive.Manifest.Created.Format("2006-01-02 15:04"))
+	if err := ui.API.As(userID).RecordEvent("restore", archive.Manifest.Host, ""); err != nil {
+		log.Printf("Failed to record restore in audit log: %v", err)
+	}
+
+	// Apply restarts the bot last, so the messages below still go out
+	snapshot, err := archive.Apply(config.Dir, config.SnapshotDir, key, ui.checkBotConfig)
+	done()
+	if err != nil && !errors.Is(err, backup.ErrBotNotRestarted) {
+		log.Printf("Restore failed: %v", err)
+		ui.Error(chatID, "Restore gagal: "+err.Error())
+		return
+	}
+
+	text := "✅ Restore Berhasil!\n\n" + backup.Summary(archive.Manifest) + "\n\nSnapshot sebelum restore: " + snapshot
+	if err != nil {
+		log.Printf("Restore: %v", err)
+		text += "\n\n⚠️ " + err.Error()
+	} else {
+		text += "\n⏳ Bot sedang di-restart. Jika bot gagal berjalan dengan konfigurasi dari backup, konfigurasi bot sebelumnya dikembalikan otomatis."
+	}
+	ui.send(chatID, text)
+}
+
+// checkBotConfig makes sure the restored bot-config.json lets the bot start:
+// it loads and Telegram accepts the token
+func (ui *UI) checkBotConfig() error {
+	data, err := os.ReadFile(config.BotConfigFile)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, ui.NewConfig()); err != nil {
+		return err
+	}
+	var cfg struct {
+		BotToken string `json:"bot_token"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+	_, err = tgbotapi.NewBotAPI(cfg.BotToken)
+	return err
+}
+
+// RunBackupSchedule makes automatic backups on s, keeps them in
+// config.BackupDir and sends them to s.BackupChatID (default adminID). It
+// returns only when automatic backups are off or misconfigured.
+func (ui *UI) RunBackupSchedule(s backup.Schedule, adminID int64) {
+	if !s.Enabled() {
+		return
+	}
+	last := backup.Latest(config.BackupDir)
+	for {
+		next, err := s.Next(last, time.Now())
+		if err != nil {
+			log.Printf("Automatic backups disabled: %v", err)
+			ui.send(adminID, "⚠️ Backup otomatis dinonaktifkan: "+err.Error())
+			return
+		}
+		time.Sleep(time.Until(next))
+		ui.scheduledBackup(s, adminID)
+		last = time.Now()
+	}
+}
+
+// scheduledBackup makes one automatic backup. Failures are always reported
+// to adminID, also when backups go to a channel.
+func (ui *UI) scheduledBackup(s backup.Schedule, adminID int64) {
+	chatID := s.BackupChatID
+	if chatID == 0 {
+		chatID = adminID
+	}
+	fail := func(text string, err error) {
+		log.Printf("Automatic backup failed: %s: %v", text, err)
+		ui.send(adminID, fmt.Sprintf("⚠️ Backup otomatis gagal: %s\n%v", text, err))
+	}
+
+	key, created, err := backup.LoadOrCreateKey(config.BackupKeyFile)
+	if err != nil {
+		fail("kunci backup tidak valid", err)
+		return
+	}
+	data, manifest, err := backup.Create(config.Dir, ui.Source, key)
+	if err != nil {
+		fail("tidak bisa membuat backup", err)
+		return
+	}
+	path, removed, err := backup.Store(config.BackupDir, data, manifest, s.Keep())
+	if err != nil {
+		fail("tidak bisa menyimpan salinan di "+config.BackupDir, err)
+		return
+	}
+	log.Printf("Automatic backup saved to %s (%d old copies removed)", path, removed)
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: filepath.Base(path), Bytes: data})
+	doc.Caption = "🗓 Backup Otomatis ZiVPN (terenkripsi " + key.Describe() + ")\n\n" + backup.Summary(manifest)
+	if _, err := ui.Bot.Send(doc); err != nil {
+		fail(fmt.Sprintf("tersimpan di %s tetapi tidak terkirim ke chat %d", path, chatID), err)
+		return
+	}
+	if created {
+		ui.send(adminID, backup.KeyNotice)
+	}
+}
@@ -0,0 +1,154 @@
+// Package botui holds the screens and admin tools shared by the free and
+// paid bots: the "my accounts" menu, bulk commands, user import/export, the
+// audit log, and manual and scheduled backups.
+//
+// The bots differ in how they track their menu message and word their
+// errors, so UI calls back into them through its hook fields, the same way
+// flow.Engine does.
+package botui
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zivpn/internal/apiclient"
+	"zivpn/internal/config"
+	"zivpn/internal/storage"
+	"zivpn/internal/telegram"
+)
+
+// Store keeps confirmations pending between a preview and its button.
+// *session.Manager implements it.
+type Store interface {
+	Get(userID int64, key string) string
+	Set(userID, chatID int64, key, value string)
+	Reset(userID int64)
+}
+
+// Callback data of the confirmation buttons. Bots route them to
+// ConfirmBulk, ConfirmImport and ConfirmRestore.
+const (
+	CallbackBulk    = "bulk_ok"
+	CallbackImport  = "import_ok"
+	CallbackRestore = "restore_ok"
+)
+
+// UI sends the shared screens of one bot
+type UI struct {
+	Bot    *tgbotapi.BotAPI
+	API    *apiclient.Client
+	Store  Store
+	Source string // binary name recorded in backups
+
+	// Show replaces the menu message of the chat with msg
+	Show func(msg tgbotapi.MessageConfig)
+	// DeleteLast removes the menu message of the chat
+	DeleteLast func(chatID int64)
+	// Error shows text, which has no prefix, as an error
+	Error func(chatID int64, text string)
+	// AccountInfo shows the connection details of an account
+	AccountInfo func(chatID int64, acc apiclient.Account)
+	// NewConfig returns an empty BotConfig of the bot, used to check the
+	// bot-config.json of a backup before it is restored
+	NewConfig func() interface{}
+
+	BackLabel string
+}
+
+// New creates a UI with plain defaults for the hooks
+func New(bot *tgbotapi.BotAPI, api *apiclient.Client, store Store, source string) *UI {
+	return &UI{
+		Bot:         bot,
+		API:         api,
+		Store:       store,
+		Source:      source,
+		Show:        func(msg tgbotapi.MessageConfig) { bot.Send(msg) },
+		DeleteLast:  func(chatID int64) {},
+		Error:       func(chatID int64, text string) { bot.Send(tgbotapi.NewMessage(chatID, "❌ "+text)) },
+		AccountInfo: func(chatID int64, acc apiclient.Account) {},
+		NewConfig:   func() interface{} { return &struct{}{} },
+		BackLabel:   "🔙 Kembali",
+	}
+}
+
+// APIError reports a failed API call. Messages from the API itself are
+// already in Indonesian; anything else means the API could not be reached.
+func (ui *UI) APIError(chatID int64, action string, err error) {
+	log.Printf("API %s failed: %v", action, err)
+	var apiErr *apiclient.Error
+	if errors.As(err, &apiErr) {
+		ui.Error(chatID, fmt.Sprintf("Gagal %s: %s", action, apiErr.Message))
+		return
+	}
+	ui.Error(chatID, "Gagal terhubung ke API ZiVPN: "+err.Error())
+}
+
+// send delivers a message outside the tracked menu message
+func (ui *UI) send(chatID int64, text string) {
+	ui.Bot.Send(tgbotapi.NewMessage(chatID, text))
+}
+
+// status shows a progress message and returns a func removing it
+func (ui *UI) status(chatID int64, text string) func() {
+	sent, err := ui.Bot.Send(tgbotapi.NewMessage(chatID, text))
+	if err != nil {
+		return func() {}
+	}
+	return func() {
+		if err := telegram.DeleteMessage(ui.Bot, chatID, sent.MessageID); err != nil {
+			log.Printf("Failed to delete message %d in chat %d: %v", sent.MessageID, chatID, err)
+		}
+	}
+}
+
+// confirm shows text with a confirmation button and a cancel button
+func (ui *UI) confirm(chatID int64, text, label, callback string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, callback),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Batal", "cancel"),
+		),
+	)
+	ui.Show(msg)
+}
+
+// logAdmin appends an entry to the admin audit log
+func logAdmin(userID int64, action, detail string) {
+	if err := storage.LogAdminAction(config.AdminLogFile, userID, action, detail); err != nil {
+		log.Printf("Failed to write admin log: %v", err)
+	}
+}
+
+// RemainingDays counts the days left until expired (YYYY-MM-DD), today
+// included
+func RemainingDays(expired string) int {
+	exp, err := time.ParseInLocation("2006-01-02", expired, time.Local)
+	if err != nil {
+		return 0
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	days := int(exp.Sub(today).Hours()/24) + 1
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// GeneratePassword returns a random password without look-alike characters
+// (l, 1, o, 0)
+func GeneratePassword() string {
+	const chars = "abcdefghijkmnpqrstuvwxyz23456789"
+	b := make([]byte, 10)
+	rand.Read(b)
+	for i := range b {
+		b[i] = chars[int(b[i])%len(chars)]
+	}
+	return string(b)
+}
@@ -0,0 +1,268 @@
+// Package bulk holds the admin tools of the free and paid bots that change
+// many accounts at once: the /bulk* commands and CSV/JSON import and export.
+package bulk
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"zivpn/internal/apiclient"
+)
+
+// Usage lists the commands, shown when one is typed wrong
+const Usage = "Perintah massal:\n" +
+	"/bulkcreate <prefix> <jumlah> <hari>\n" +
+	"/bulkextend <hari> [filter]\n" +
+	"/bulkdelete <hari_sejak_expired> [filter]\n" +
+	"/bulklock <filter|all>\n" +
+	"/bulkunlock <filter|all>\n" +
+	"/compensate <mulai> <selesai> [notify] [days=<n>] [filter] [alasan]\n\n" +
+	"Waktu: YYYY-MM-DDTHH:MM atau YYYY-MM-DD. notify mengabari pemilik akun.\n" + filterHelp
+
+const filterHelp = "Filter: status=active|locked|expired owner_id=<id> q=<kata> expiring_days=<n> created_from=YYYY-MM-DD created_to=YYYY-MM-DD"
+
+// Command is a parsed bulk command
+type Command struct {
+	Name       string
+	Create     apiclient.BulkCreateRequest // bulkcreate
+	Compensate apiclient.CompensateRequest // compensate
+	Options    apiclient.BulkOptions       // the other commands
+	Days       int                         // bulkextend, bulkdelete
+	Notify     bool                        // compensate: message the account owners
+}
+
+// Parse reads the command name and its arguments, e.g. "bulkextend" and
+// "3 status=active"
+func Parse(name, args string) (Command, error) {
+	cmd := Command{Name: name}
+	fields := strings.Fields(args)
+
+	switch name {
+	case "bulkcreate":
+		if len(fields) != 3 {
+			return cmd, fmt.Errorf("Format: /bulkcreate <prefix> <jumlah> <hari>")
+		}
+		count, err1 := strconv.Atoi(fields[1])
+		days, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			return cmd, fmt.Errorf("Jumlah dan hari harus angka")
+		}
+		cmd.Create = apiclient.BulkCreateRequest{Prefix: fields[0], Count: count, Days: days}
+		return cmd, nil
+
+	case "bulkextend", "bulkdelete":
+		if len(fields) < 1 {
+			return cmd, fmt.Errorf("Format: /%s <hari> [filter]", name)
+		}
+		days, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return cmd, fmt.Errorf("Hari harus angka")
+		}
+		cmd.Days = days
+		fields = fields[1:]
+
+	case "compensate":
+		if len(fields) < 2 {
+			return cmd, fmt.Errorf("Format: /compensate <mulai> <selesai> [notify] [days=<n>] [filter] [alasan]")
+		}
+		cmd.Compensate = apiclient.CompensateRequest{From: fields[0], To: fields[1]}
+		// Words that are not key=value form the reason kept in the audit log
+		var rest, reason []string
+		for _, field := range fields[2:] {
+			switch {
+			case field == "notify":
+				cmd.Notify = true
+			case strings.HasPrefix(field, "days="):
+				days, err := strconv.Atoi(strings.TrimPrefix(field, "days="))
+				if err != nil {
+					return cmd, fmt.Errorf("Nilai days harus angka")
+				}
+				cmd.Compensate.Days = days
+			case strings.Contains(field, "="):
+				rest = append(rest, field)
+			default:
+				reason = append(reason, field)
+			}
+		}
+		cmd.Compensate.Reason = strings.Join(reason, " ")
+		fields = rest
+
+	case "bulklock", "bulkunlock":
+		if len(fields) == 0 {
+			return cmd, fmt.Errorf("Format: /%s <filter|all>", name)
+		}
+		if len(fields) == 1 && fields[0] == "all" {
+			cmd.Options.All = true
+			return cmd, nil
+		}
+
+	default:
+		return cmd, fmt.Errorf("perintah tidak dikenal: %s", name)
+	}
+
+	filter, err := ParseFilter(fields)
+	if err != nil {
+		return cmd, err
+	}
+	cmd.Options.Filter = filter
+	return cmd, nil
+}
+
+// ParseFilter reads key=value pairs named like the GET /api/users query
+func ParseFilter(fields []string) (apiclient.ListOptions, error) {
+	var f apiclient.ListOptions
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return f, fmt.Errorf("Filter harus berbentuk kunci=nilai: %s", field)
+		}
+		var err error
+		switch key {
+		case "status":
+			f.Status = value
+		case "owner_id":
+			f.OwnerID, err = strconv.ParseInt(value, 10, 64)
+		case "q":
+			f.Search = value
+		case "expiring_days":
+			f.ExpiringDays, err = strconv.Atoi(value)
+		case "created_from":
+			f.CreatedFrom = value
+		case "created_to":
+			f.CreatedTo = value
+		default:
+			return f, fmt.Errorf("Filter tidak dikenal: %s", key)
+		}
+		if err != nil {
+			return f, fmt.Errorf("Nilai %s harus angka", key)
+		}
+	}
+	return f, nil
+}
+
+// NeedsConfirm reports whether the command should be previewed with a dry
+// run first. Creating accounts destroys nothing, so it runs right away.
+func (c Command) NeedsConfirm() bool {
+	return c.Name != "bulkcreate"
+}
+
+// Run executes the command, or only reports what it would change when dryRun is set
+func (c Command) Run(api *apiclient.Client, dryRun bool) (apiclient.BulkResult, error) {
+	opts := c.Options
+	opts.DryRun = dryRun
+	switch c.Name {
+	case "bulkcreate":
+		return api.BulkCreate(c.Create, dryRun)
+	case "bulkextend":
+		return api.BulkExtend(opts, c.Days)
+	case "bulkdelete":
+		return api.BulkDelete(opts, c.Days)
+	case "bulklock":
+		return api.BulkLock(opts)
+	case "bulkunlock":
+		return api.BulkUnlock(opts)
+	case "compensate":
+		return api.Compensate(opts, c.Compensate)
+	}
+	return apiclient.BulkResult{}, fmt.Errorf("perintah tidak dikenal: %s", c.Name)
+}
+
+// Summary describes a result as plain text, listing at most max accounts
+func Summary(res apiclient.BulkResult, max int) string {
+	var sb strings.Builder
+	sb.WriteString(res.Message)
+	if res.Days > 0 {
+		sb.WriteString(fmt.Sprintf(" (+%d hari)", res.Days))
+	}
+	for i, u := range res.Accounts {
+		if i == max {
+			sb.WriteString(fmt.Sprintf("\n... dan %d akun lainnya", len(res.Accounts)-max))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("\n%s (exp %s)", u.Password, u.Expired))
+	}
+	if res.Domain != "" {
+		sb.WriteString("\n\nDomain: " + res.Domain)
+	}
+	return sb.String()
+}
+
+// CompensationNotices builds one message per account owner listing their
+// compensated accounts. Accounts without an owner are left out.
+func CompensationNotices(res apiclient.BulkResult) map[int64]string {
+	byOwner := make(map[int64][]apiclient.User)
+	for _, u := range res.Accounts {
+		if u.OwnerID != 0 {
+			byOwner[u.OwnerID] = append(byOwner[u.OwnerID], u)
+		}
+	}
+	notices := make(map[int64]string, len(byOwner))
+	for owner, accounts := range byOwner {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("🎁 KOMPENSASI GANGGUAN\n\nMohon maaf atas gangguan layanan. Masa aktif akun Anda diperpanjang %d hari:\n", res.Days))
+		for _, u := range accounts {
+			sb.WriteString(fmt.Sprintf("\n%s (expired baru %s)", u.Password, u.Expired))
+		}
+		notices[owner] = sb.String()
+	}
+	return notices
+}
+
+// ImportFormat returns "csv" or "json" for the name of an import file, or ""
+// when the file is neither
+func ImportFormat(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return "csv"
+	case ".json":
+		return "json"
+	}
+	return ""
+}
+
+// ImportPolicy reads the conflict policy from the caption of an import file.
+// Without a caption existing accounts are skipped.
+func ImportPolicy(caption string) (string, error) {
+	switch policy := strings.ToLower(strings.TrimSpace(caption)); policy {
+	case "":
+		return "skip", nil
+	case "skip", "overwrite", "merge":
+		return policy, nil
+	}
+	return "", fmt.Errorf("Caption harus skip, overwrite atau merge")
+}
+
+// ImportSummary describes an import report as plain text
+func ImportSummary(rep apiclient.ImportReport, policy string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Baris: %d (kebijakan: %s)\n", rep.Total, policy))
+	sb.WriteString(fmt.Sprintf("Dibuat: %d\nDiperbarui: %d\nDilewati: %d\nTidak valid: %d", rep.Created, rep.Updated, rep.Skipped, rep.Invalid))
+	for i, e := range rep.Errors {
+		if i == 20 {
+			sb.WriteString(fmt.Sprintf("\n... dan %d baris lainnya", rep.Invalid-20))
+			break
+		}
+		if i == 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("\nBaris %d %s: %s", e.Line, e.Password, e.Error))
+	}
+	return sb.String()
+}
+
+// ExportUsage is shown when /export is typed wrong
+const ExportUsage = "Format: /export [csv|json] [filter]\n\n" + filterHelp
+
+// ParseExport reads the arguments of /export, returning the format and filter
+func ParseExport(args string) (string, apiclient.ListOptions, error) {
+	fields := strings.Fields(args)
+	format := "csv"
+	if len(fields) > 0 && (fields[0] == "csv" || fields[0] == "json") {
+		format = fields[0]
+		fields = fields[1:]
+	}
+	filter, err := ParseFilter(fields)
+	return format, filter, err
+}
@@ -0,0 +1,102 @@
+package bulk
+
+import (
+	"reflect"
+	"testing"
+
+	"zivpn/internal/apiclient"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name, args string
+		want       Command
+		wantErr    bool
+	}{
+		{"bulkcreate", "tes 5 30", Command{Name: "bulkcreate", Create: apiclient.BulkCreateRequest{Prefix: "tes", Count: 5, Days: 30}}, false},
+		{"bulkcreate", "tes 5", Command{}, true},
+		{"bulkcreate", "tes lima 30", Command{}, true},
+		{"bulkextend", "3 status=active owner_id=42", Command{Name: "bulkextend", Days: 3,
+			Options: apiclient.BulkOptions{Filter: apiclient.ListOptions{Status: "active", OwnerID: 42}}}, false},
+		{"bulkextend", "", Command{}, true},
+		{"bulkdelete", "7 q=tes expiring_days=2", Command{Name: "bulkdelete", Days: 7,
+			Options: apiclient.BulkOptions{Filter: apiclient.ListOptions{Search: "tes", ExpiringDays: 2}}}, false},
+		{"bulkdelete", "tujuh", Command{}, true},
+		{"bulklock", "all", Command{Name: "bulklock", Options: apiclient.BulkOptions{All: true}}, false},
+		{"bulkunlock", "created_from=2024-01-01 created_to=2024-02-01", Command{Name: "bulkunlock",
+			Options: apiclient.BulkOptions{Filter: apiclient.ListOptions{CreatedFrom: "2024-01-01", CreatedTo: "2024-02-01"}}}, false},
+		{"bulklock", "", Command{}, true},
+		{"bulklock", "status", Command{}, true},
+		{"bulklock", "owner_id=abc", Command{}, true},
+		{"bulklock", "color=red", Command{}, true},
+		{"compensate", "2024-05-01T10:00 2024-05-01T14:00 notify days=2 status=active server mati",
+			Command{Name: "compensate", Notify: true,
+				Compensate: apiclient.CompensateRequest{From: "2024-05-01T10:00", To: "2024-05-01T14:00", Days: 2, Reason: "server mati"},
+				Options:    apiclient.BulkOptions{Filter: apiclient.ListOptions{Status: "active"}}}, false},
+		{"compensate", "2024-05-01", Command{}, true},
+		{"compensate", "2024-05-01 2024-05-02 days=dua", Command{}, true},
+		{"bulkpurge", "all", Command{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.args, func(t *testing.T) {
+			got, err := Parse(tt.name, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExport(t *testing.T) {
+	tests := []struct {
+		args       string
+		wantFormat string
+		wantFilter apiclient.ListOptions
+		wantErr    bool
+	}{
+		{"", "csv", apiclient.ListOptions{}, false},
+		{"json", "json", apiclient.ListOptions{}, false},
+		{"status=locked", "csv", apiclient.ListOptions{Status: "locked"}, false},
+		{"json owner_id=7", "json", apiclient.ListOptions{OwnerID: 7}, false},
+		{"xml", "", apiclient.ListOptions{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			format, filter, err := ParseExport(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (format != tt.wantFormat || filter != tt.wantFilter) {
+				t.Errorf("got %s %+v, want %s %+v", format, filter, tt.wantFormat, tt.wantFilter)
+			}
+		})
+	}
+}
+
+func TestImportPolicyAndFormat(t *testing.T) {
+	policies := []struct {
+		caption, want string
+		wantErr       bool
+	}{
+		{"", "skip", false},
+		{" Overwrite ", "overwrite", false},
+		{"merge", "merge", false},
+		{"replace", "", true},
+	}
+	for _, tt := range policies {
+		got, err := ImportPolicy(tt.caption)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ImportPolicy(%q) = %q, %v", tt.caption, got, err)
+		}
+	}
+
+	formats := map[string]string{"users.csv": "csv", "USERS.JSON": "json", "users.txt": "", "csv": ""}
+	for name, want := range formats {
+		if got := ImportFormat(name); got != want {
+			t.Errorf("ImportFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Admin permissions. The owner always has all of them.
+const (
+	PermUsers  = "users"  // manage accounts (and customer balances in the paid bot)
+	PermBackup = "backup" // backup & restore
+	PermMode   = "mode"   // toggle public/private mode
+	PermSales  = "sales"  // view sales reports
+	PermPrices = "prices" // manage plans, vouchers and resellers
+)
+
+// AllPermissions lists every permission, in the order shown to users
+var AllPermissions = []string{PermUsers, PermBackup, PermMode, PermSales, PermPrices}
+
+// Admin is a delegated admin with a subset of the permissions
+type Admin struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// Access is the admin part of bot-config.json. Both bots embed it in their
+// BotConfig, so the JSON keys stay at the top level.
+type Access struct {
+	AdminID int64   `json:"admin_id"` // owner
+	Admins  []Admin `json:"admins"`   // delegated admins besides the owner
+}
+
+// IsAdmin reports whether userID is the owner or one of the delegated admins
+func (a *Access) IsAdmin(userID int64) bool {
+	if userID == a.AdminID {
+		return true
+	}
+	for _, admin := range a.Admins {
+		if admin.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether userID holds the given permission
+func (a *Access) Can(userID int64, perm string) bool {
+	if userID == a.AdminID {
+		return true
+	}
+	for _, admin := range a.Admins {
+		if admin.ID != userID {
+			continue
+		}
+		for _, p := range admin.Permissions {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParsePermissions turns "users,sales" or "all" into a validated list
+func ParsePermissions(raw string) ([]string, error) {
+	if raw == "all" {
+		return AllPermissions, nil
+	}
+	var perms []string
+	for _, p := range strings.Split(raw, ",") {
+		p = strings.TrimSpace(p)
+		valid := false
+		for _, known := range AllPermissions {
+			if p == known {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("izin tidak dikenal: %s", p)
+		}
+		perms = append(perms, p)
+	}
+	return perms, nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePermissions(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{"all", AllPermissions, false},
+		{"users", []string{PermUsers}, false},
+		{"users, backup,sales", []string{PermUsers, PermBackup, PermSales}, false},
+		{"users,root", nil, true},
+		{"", nil, true},
+		{"users,", nil, true},
+		{"ALL", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParsePermissions(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessCan(t *testing.T) {
+	a := &Access{AdminID: 1, Admins: []Admin{{ID: 2, Permissions: []string{PermBackup}}}}
+	tests := []struct {
+		name   string
+		userID int64
+		perm   string
+		want   bool
+	}{
+		{"owner has everything", 1, PermPrices, true},
+		{"admin with permission", 2, PermBackup, true},
+		{"admin without permission", 2, PermUsers, false},
+		{"not an admin", 3, PermBackup, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.Can(tt.userID, tt.perm); got != tt.want {
+				t.Errorf("Can(%d, %s) = %v, want %v", tt.userID, tt.perm, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Package config holds the file locations shared by the API and the bots and
+// the settings every binary reads at startup (API key, API port, domain).
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Files under Dir written by install.sh and read by more than one binary
+const (
+	Dir           = "/etc/zivpn"
+	ServerConfig  = Dir + "/config.json" // ZiVPN server config
+	UsersFile     = Dir + "/users.json"
+	DomainFile    = Dir + "/domain"
+	ApiKeyFile    = Dir + "/apikey"
+	ApiPortFile   = Dir + "/api_port"
+	BotConfigFile = Dir + "/bot-config.json"
+	AuditLog      = Dir + "/audit.log"         // account changes, written by the API
+	AdminLogFile  = Dir + "/admin-audit.log"   // admin actions, written by the bots
+	BackupKeyFile = Dir + "/backup.key"        // passphrase or X25519 key of the backups, never backed up
+	BackupDir     = Dir + "/backups"           // automatic backups, rotated by the bots
+	SnapshotDir   = BackupDir + "/pre-restore" // state before each restore, rotated separately
+)
+
+// JSON stores of the paid bot under Dir, backed up with the files above
+const (
+	PlansFile     = Dir + "/plans.json"
+	VouchersFile  = Dir + "/vouchers.json"
+	WalletsFile   = Dir + "/wallets.json"
+	ResellersFile = Dir + "/resellers.json"
+	OrdersFile    = Dir + "/orders.json"
+)
+
+// DefaultApiKey is used when ApiKeyFile is missing
+const DefaultApiKey = "AutoFtBot-agskjgdvsbdreiWG1234512SDKrqw"
+
+// DefaultApiPort is used when ApiPortFile is missing
+const DefaultApiPort = 8080
+
+// LoadApiKey returns the API key from ApiKeyFile. On error DefaultApiKey is
+// returned together with the error, so callers can log it and continue.
+func LoadApiKey() (string, error) {
+	data, err := os.ReadFile(ApiKeyFile)
+	if err != nil {
+		return DefaultApiKey, err
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return DefaultApiKey, fmt.Errorf("%s kosong", ApiKeyFile)
+	}
+	return key, nil
+}
+
+// LoadApiPort returns the port from ApiPortFile, or DefaultApiPort with the error
+func LoadApiPort() (int, error) {
+	data, err := os.ReadFile(ApiPortFile)
+	if err != nil {
+		return DefaultApiPort, err
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || port <= 0 || port > 65535 {
+		return DefaultApiPort, fmt.Errorf("port API tidak valid: %q", strings.TrimSpace(string(data)))
+	}
+	return port, nil
+}
+
+// ApiURL returns the base URL of the local API, e.g. http://127.0.0.1:8080/api
+func ApiURL(port int) string {
+	return fmt.Sprintf("http://127.0.0.1:%d/api", port)
+}
+
+// LoadDomain returns the domain chosen during installation, "" if unknown
+func LoadDomain() string {
+	data, err := os.ReadFile(DomainFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
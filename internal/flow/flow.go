@@ -0,0 +1,267 @@
+// Package flow runs multi-step bot conversations declared as data.
+//
+// A Flow lists its Steps (prompt, validator, key to store the answer under)
+// and a Finish action. The Engine keeps track of the current step in a
+// Store, shows every prompt with Back and Cancel buttons and calls Finish
+// once all answers are collected.
+package flow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Callback data of the built-in buttons
+const (
+	CallbackBack   = "flow_back"
+	CallbackCancel = "flow_cancel"
+)
+
+const statePrefix = "flow:"
+
+// Store keeps the conversation state. *session.Manager implements it.
+type Store interface {
+	State(userID int64) (string, bool)
+	SetState(userID, chatID int64, state string)
+	Get(userID int64, key string) string
+	Set(userID, chatID int64, key, value string)
+	Data(userID int64) map[string]string
+	Reset(userID int64)
+}
+
+// Context is passed to prompts, validators and actions
+type Context struct {
+	UserID  int64
+	ChatID  int64
+	Data    map[string]string
+	Message *tgbotapi.Message // message that answered the current step, nil when prompting
+}
+
+// Validator checks an answer and returns the value to store
+type Validator func(input string, ctx *Context) (string, error)
+
+// Step is one question of a flow
+type Step struct {
+	Key        string
+	Prompt     string
+	PromptFunc func(ctx *Context) string // overrides Prompt when set
+	Validate   Validator                 // nil accepts any non-empty answer
+	Document   bool                      // expects a file, the stored value is its file ID
+}
+
+// Flow is a declared conversation
+type Flow struct {
+	Name  string
+	Steps []Step
+	// Finish runs after the last step. Returning an error shows it and asks
+	// the last step again, so the user can correct the answer.
+	Finish func(ctx *Context) error
+	// OnCancel runs after the user cancels, e.g. to show the main menu
+	OnCancel func(ctx *Context)
+}
+
+// Engine dispatches updates to the registered flows
+type Engine struct {
+	bot   *tgbotapi.BotAPI
+	store Store
+	flows map[string]*Flow
+
+	// Send delivers prompts. Defaults to bot.Send; bots override it to track
+	// and clean up their menu messages.
+	Send func(msg tgbotapi.MessageConfig)
+	// DeleteInput removes the user's answers from the chat (they may contain passwords)
+	DeleteInput bool
+	ParseMode   string // applied to prompts, "" sends plain text
+	BackLabel   string
+	CancelLabel string
+}
+
+// New creates an engine storing its state in store
+func New(bot *tgbotapi.BotAPI, store Store) *Engine {
+	return &Engine{
+		bot:         bot,
+		store:       store,
+		flows:       make(map[string]*Flow),
+		Send:        func(msg tgbotapi.MessageConfig) { bot.Send(msg) },
+		ParseMode:   tgbotapi.ModeMarkdown,
+		BackLabel:   "⬅️ Kembali",
+		CancelLabel: "❌ Batal",
+	}
+}
+
+// Register adds a flow. Names must be unique and must not contain ':'.
+func (e *Engine) Register(f *Flow) {
+	e.flows[f.Name] = f
+}
+
+// Start begins flow name for userID, optionally with data known in advance
+func (e *Engine) Start(name string, userID, chatID int64, initial map[string]string) error {
+	f, ok := e.flows[name]
+	if !ok {
+		return fmt.Errorf("flow %s tidak terdaftar", name)
+	}
+	e.store.Reset(userID)
+	for k, v := range initial {
+		e.store.Set(userID, chatID, k, v)
+	}
+	e.goTo(f, 0, userID, chatID)
+	return nil
+}
+
+// Active reports whether userID is in the middle of a flow
+func (e *Engine) Active(userID int64) bool {
+	_, _, ok := e.current(userID)
+	return ok
+}
+
+// Stop silently ends the flow of userID, if any
+func (e *Engine) Stop(userID int64) {
+	if e.Active(userID) {
+		e.store.Reset(userID)
+	}
+}
+
+// HandleMessage feeds a message to the active flow. It returns false when the
+// user is not in a flow so the caller can handle the message itself.
+func (e *Engine) HandleMessage(msg *tgbotapi.Message) bool {
+	userID, chatID := msg.From.ID, msg.Chat.ID
+	f, idx, ok := e.current(userID)
+	if !ok {
+		return false
+	}
+	if e.DeleteInput && msg.Document == nil {
+		e.bot.Request(tgbotapi.NewDeleteMessage(chatID, msg.MessageID))
+	}
+
+	step := f.Steps[idx]
+	ctx := &Context{UserID: userID, ChatID: chatID, Data: e.store.Data(userID), Message: msg}
+
+	var input string
+	if step.Document {
+		if msg.Document == nil {
+			e.retry(f, idx, ctx, "Silakan kirim file, bukan teks.")
+			return true
+		}
+		input = msg.Document.FileID
+	} else {
+		input = strings.TrimSpace(msg.Text)
+		if input == "" {
+			e.retry(f, idx, ctx, "Jawaban tidak boleh kosong.")
+			return true
+		}
+	}
+
+	value := input
+	if step.Validate != nil {
+		v, err := step.Validate(input, ctx)
+		if err != nil {
+			e.retry(f, idx, ctx, err.Error())
+			return true
+		}
+		value = v
+	}
+	e.store.Set(userID, chatID, step.Key, value)
+	ctx.Data[step.Key] = value
+
+	if idx+1 < len(f.Steps) {
+		e.goTo(f, idx+1, userID, chatID)
+		return true
+	}
+
+	// Reset first so Finish may start another flow or conversation
+	e.store.Reset(userID)
+	if f.Finish == nil {
+		return true
+	}
+	if err := f.Finish(ctx); err != nil {
+		for k, v := range ctx.Data {
+			e.store.Set(userID, chatID, k, v)
+		}
+		e.store.SetState(userID, chatID, stateName(f, idx))
+		e.retry(f, idx, ctx, err.Error())
+	}
+	return true
+}
+
+// HandleCallback handles the Back and Cancel buttons. It returns false for
+// any other callback.
+func (e *Engine) HandleCallback(query *tgbotapi.CallbackQuery) bool {
+	if query.Data != CallbackBack && query.Data != CallbackCancel {
+		return false
+	}
+	e.bot.Request(tgbotapi.NewCallback(query.ID, ""))
+
+	userID, chatID := query.From.ID, query.Message.Chat.ID
+	f, idx, ok := e.current(userID)
+	if !ok {
+		return true
+	}
+
+	if query.Data == CallbackBack && idx > 0 {
+		e.goTo(f, idx-1, userID, chatID)
+		return true
+	}
+
+	ctx := &Context{UserID: userID, ChatID: chatID, Data: e.store.Data(userID)}
+	e.store.Reset(userID)
+	e.bot.Request(tgbotapi.NewDeleteMessage(chatID, query.Message.MessageID))
+	if f.OnCancel != nil {
+		f.OnCancel(ctx)
+	} else {
+		e.Send(tgbotapi.NewMessage(chatID, "❌ Dibatalkan."))
+	}
+	return true
+}
+
+func (e *Engine) current(userID int64) (*Flow, int, bool) {
+	state, ok := e.store.State(userID)
+	if !ok || !strings.HasPrefix(state, statePrefix) {
+		return nil, 0, false
+	}
+	parts := strings.Split(strings.TrimPrefix(state, statePrefix), ":")
+	if len(parts) != 2 {
+		return nil, 0, false
+	}
+	f, ok := e.flows[parts[0]]
+	idx, err := strconv.Atoi(parts[1])
+	if !ok || err != nil || idx < 0 || idx >= len(f.Steps) {
+		return nil, 0, false
+	}
+	return f, idx, true
+}
+
+func (e *Engine) goTo(f *Flow, idx int, userID, chatID int64) {
+	e.store.SetState(userID, chatID, stateName(f, idx))
+	ctx := &Context{UserID: userID, ChatID: chatID, Data: e.store.Data(userID)}
+	e.prompt(f, idx, ctx, "")
+}
+
+func (e *Engine) retry(f *Flow, idx int, ctx *Context, problem string) {
+	e.prompt(f, idx, ctx, "❌ "+problem+"\n\n")
+}
+
+func (e *Engine) prompt(f *Flow, idx int, ctx *Context, prefix string) {
+	step := f.Steps[idx]
+	text := step.Prompt
+	if step.PromptFunc != nil {
+		text = step.PromptFunc(ctx)
+	}
+
+	buttons := []tgbotapi.InlineKeyboardButton{}
+	if idx > 0 {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(e.BackLabel, CallbackBack))
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(e.CancelLabel, CallbackCancel))
+
+	msg := tgbotapi.NewMessage(ctx.ChatID, prefix+text)
+	msg.ParseMode = e.ParseMode
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons)
+	e.Send(msg)
+}
+
+func stateName(f *Flow, idx int) string {
+	return fmt.Sprintf("%s%s:%d", statePrefix, f.Name, idx)
+}
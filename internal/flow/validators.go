@@ -0,0 +1,41 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var passwordPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Password accepts 3-20 letters, digits, '-' or '_', the format used for ZiVPN accounts
+func Password(input string, ctx *Context) (string, error) {
+	if len(input) < 3 || len(input) > 20 {
+		return "", errors.New("Password harus 3-20 karakter. Coba lagi:")
+	}
+	if !passwordPattern.MatchString(input) {
+		return "", errors.New("Password hanya boleh huruf, angka, strip (-), dan underscore (_). Coba lagi:")
+	}
+	return input, nil
+}
+
+// Number accepts an integer between min and max
+func Number(min, max int, field string) Validator {
+	return func(input string, ctx *Context) (string, error) {
+		val, err := strconv.Atoi(input)
+		if err != nil || val < min || val > max {
+			return "", fmt.Errorf("%s harus angka (%d-%d). Coba lagi:", field, min, max)
+		}
+		return strconv.Itoa(val), nil
+	}
+}
+
+// Keyword accepts a search term of up to 20 characters in the password
+// alphabet, so it can be carried in callback data
+func Keyword(input string, ctx *Context) (string, error) {
+	if len(input) > 20 || !passwordPattern.MatchString(input) {
+		return "", errors.New("Kata kunci maksimal 20 karakter: huruf, angka, strip (-), atau underscore (_). Coba lagi:")
+	}
+	return input, nil
+}
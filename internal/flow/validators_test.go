@@ -0,0 +1,41 @@
+package flow
+
+import "testing"
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name      string
+		validate  Validator
+		input     string
+		want      string
+		wantError bool
+	}{
+		{"password", Password, "budi_123", "budi_123", false},
+		{"password with dash", Password, "a-b", "a-b", false},
+		{"password too short", Password, "ab", "", true},
+		{"password too long", Password, "abcdefghijklmnopqrstu", "", true},
+		{"password with space", Password, "budi 123", "", true},
+		{"password with symbol", Password, "budi@123", "", true},
+		{"number", Number(1, 30, "Hari"), "30", "30", false},
+		{"number normalised", Number(1, 30, "Hari"), "007", "7", false},
+		{"number below min", Number(1, 30, "Hari"), "0", "", true},
+		{"number above max", Number(1, 30, "Hari"), "31", "", true},
+		{"number not a number", Number(1, 30, "Hari"), "tiga", "", true},
+		{"keyword", Keyword, "bud", "bud", false},
+		{"keyword of one letter", Keyword, "b", "b", false},
+		{"keyword empty", Keyword, "", "", true},
+		{"keyword too long", Keyword, "abcdefghijklmnopqrstu", "", true},
+		{"keyword with colon", Keyword, "page:2", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.validate(tt.input, &Context{})
+			if (err != nil) != tt.wantError {
+				t.Fatalf("err = %v, wantError %v", err, tt.wantError)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+// Package session keeps per-user conversation state for the Telegram bots.
+//
+// Handlers run in their own goroutines, so every access goes through a
+// Manager which serialises reads and writes, expires abandoned
+// conversations and can persist them to disk across restarts.
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Session is the conversation state of one Telegram user
+type Session struct {
+	ChatID    int64             `json:"chat_id"`
+	State     string            `json:"state"`
+	Data      map[string]string `json:"data"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// ExpireFunc is called for every session removed by the janitor
+type ExpireFunc func(userID int64, s Session)
+
+// Manager is a goroutine-safe store of sessions and of the last message the
+// bot sent to each chat (used to clean up old menus).
+type Manager struct {
+	mu       sync.Mutex
+	sessions map[int64]*Session
+	messages map[int64]int
+	ttl      time.Duration
+	file     string
+}
+
+type snapshot struct {
+	Sessions map[int64]*Session `json:"sessions"`
+	Messages map[int64]int      `json:"messages"`
+}
+
+// NewManager creates a manager whose sessions expire after ttl of inactivity
+// (0 disables expiry). When file is not empty, state is loaded from it and
+// written back after every change.
+func NewManager(ttl time.Duration, file string) *Manager {
+	m := &Manager{
+		sessions: make(map[int64]*Session),
+		messages: make(map[int64]int),
+		ttl:      ttl,
+		file:     file,
+	}
+	if file != "" {
+		if data, err := os.ReadFile(file); err == nil {
+			var snap snapshot
+			if json.Unmarshal(data, &snap) == nil {
+				for id, s := range snap.Sessions {
+					if s.Data == nil {
+						s.Data = make(map[string]string)
+					}
+					m.sessions[id] = s
+				}
+				for id, msgID := range snap.Messages {
+					m.messages[id] = msgID
+				}
+			}
+		}
+	}
+	return m
+}
+
+// State returns the current state of userID
+func (m *Manager) State(userID int64) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[userID]
+	if !ok || s.State == "" {
+		return "", false
+	}
+	return s.State, true
+}
+
+// SetState moves userID to state, creating the session if needed
+func (m *Manager) SetState(userID, chatID int64, state string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.session(userID, chatID)
+	s.State = state
+	m.save()
+}
+
+// ClearState removes the state but keeps the collected data
+func (m *Manager) ClearState(userID int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[userID]; ok {
+		s.State = ""
+		s.UpdatedAt = time.Now()
+		m.save()
+	}
+}
+
+// ClearStateIf removes the state of userID only while it is one of states,
+// so a conversation the user started in the meantime is left alone. It
+// reports whether the state was removed.
+func (m *Manager) ClearStateIf(userID int64, states ...string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[userID]
+	if !ok || s.State == "" {
+		return false
+	}
+	for _, state := range states {
+		if s.State == state {
+			s.State = ""
+			s.UpdatedAt = time.Now()
+			m.save()
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns one value collected during the conversation
+func (m *Manager) Get(userID int64, key string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[userID]; ok {
+		return s.Data[key]
+	}
+	return ""
+}
+
+// Set stores one value for the conversation
+func (m *Manager) Set(userID, chatID int64, key, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.session(userID, chatID).Data[key] = value
+	m.save()
+}
+
+// Data returns a copy of everything collected for userID
+func (m *Manager) Data(userID int64) map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data := make(map[string]string)
+	if s, ok := m.sessions[userID]; ok {
+		for k, v := range s.Data {
+			data[k] = v
+		}
+	}
+	return data
+}
+
+// Reset ends the conversation of userID
+func (m *Manager) Reset(userID int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[userID]; ok {
+		delete(m.sessions, userID)
+		m.save()
+	}
+}
+
+// SetLastMessage remembers the last message the bot sent to chatID
+func (m *Manager) SetLastMessage(chatID int64, messageID int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[chatID] = messageID
+	m.save()
+}
+
+// TakeLastMessage returns and forgets the last message sent to chatID
+func (m *Manager) TakeLastMessage(chatID int64) (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id, ok := m.messages[chatID]
+	if ok {
+		delete(m.messages, chatID)
+		m.save()
+	}
+	return id, ok
+}
+
+// ForgetLastMessage forgets messageID if it is still the last message sent
+// to chatID, so the next menu does not delete it
+func (m *Manager) ForgetLastMessage(chatID int64, messageID int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.messages[chatID] == messageID {
+		delete(m.messages, chatID)
+		m.save()
+	}
+}
+
+// StartJanitor removes sessions idle for longer than the TTL every interval
+// and reports each of them to onExpire, outside the lock.
+func (m *Manager) StartJanitor(interval time.Duration, onExpire ExpireFunc) {
+	if m.ttl <= 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(interval) {
+			for userID, s := range m.expire(time.Now()) {
+				if onExpire != nil {
+					onExpire(userID, s)
+				}
+			}
+		}
+	}()
+}
+
+func (m *Manager) expire(now time.Time) map[int64]Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expired := make(map[int64]Session)
+	for userID, s := range m.sessions {
+		if now.Sub(s.UpdatedAt) > m.ttl {
+			expired[userID] = *s
+			delete(m.sessions, userID)
+		}
+	}
+	if len(expired) > 0 {
+		m.save()
+	}
+	return expired
+}
+
+// session returns the session of userID, creating it. Caller must hold mu.
+func (m *Manager) session(userID, chatID int64) *Session {
+	s, ok := m.sessions[userID]
+	if !ok {
+		s = &Session{ChatID: chatID, Data: make(map[string]string)}
+		m.sessions[userID] = s
+	}
+	if chatID != 0 {
+		s.ChatID = chatID
+	}
+	s.UpdatedAt = time.Now()
+	return s
+}
+
+// save writes the state to file atomically. Caller must hold mu.
+func (m *Manager) save() {
+	if m.file == "" {
+		return
+	}
+	data, err := json.Marshal(snapshot{Sessions: m.sessions, Messages: m.messages})
+	if err != nil {
+		return
+	}
+	tmp := m.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return
+	}
+	os.Rename(tmp, m.file)
+}
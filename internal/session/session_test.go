@@ -0,0 +1,114 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpire(t *testing.T) {
+	const ttl = 15 * time.Minute
+	tests := []struct {
+		name    string
+		idle    time.Duration
+		expired bool
+	}{
+		{"active", time.Minute, false},
+		{"just within ttl", ttl, false},
+		{"idle past ttl", ttl + time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(ttl, "")
+			m.SetState(1, 10, "flow:create")
+			m.Set(1, 10, "password", "budi")
+
+			expired := m.expire(m.sessions[1].UpdatedAt.Add(tt.idle))
+			s, ok := expired[1]
+			if ok != tt.expired {
+				t.Fatalf("expired = %v, want %v", ok, tt.expired)
+			}
+			if _, active := m.State(1); active == tt.expired {
+				t.Errorf("state kept = %v after expiry = %v", active, tt.expired)
+			}
+			if ok && (s.ChatID != 10 || s.State != "flow:create" || s.Data["password"] != "budi") {
+				t.Errorf("expired session = %+v", s)
+			}
+		})
+	}
+}
+
+func TestActivityRefreshesSession(t *testing.T) {
+	m := NewManager(time.Minute, "")
+	m.SetState(1, 10, "flow:create")
+	start := m.sessions[1].UpdatedAt
+	m.sessions[1].UpdatedAt = start.Add(-time.Hour)
+	m.Set(1, 0, "password", "budi")
+
+	if expired := m.expire(start.Add(30 * time.Second)); len(expired) != 0 {
+		t.Fatalf("session expired after activity: %+v", expired)
+	}
+	if got := m.sessions[1].ChatID; got != 10 {
+		t.Errorf("chat ID = %d, want 10 kept when 0 is passed", got)
+	}
+}
+
+func TestPersistedSessions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sessions.json")
+	m := NewManager(time.Minute, file)
+	m.SetState(1, 10, "flow:renew")
+	m.Set(1, 10, "password", "budi")
+	m.SetLastMessage(10, 99)
+
+	loaded := NewManager(time.Minute, file)
+	if state, _ := loaded.State(1); state != "flow:renew" {
+		t.Errorf("state = %q", state)
+	}
+	if got := loaded.Get(1, "password"); got != "budi" {
+		t.Errorf("password = %q", got)
+	}
+	if id, ok := loaded.TakeLastMessage(10); !ok || id != 99 {
+		t.Errorf("last message = %d, %v", id, ok)
+	}
+}
+
+func TestClearStateIf(t *testing.T) {
+	m := NewManager(0, "")
+	m.SetState(1, 10, "flow:create")
+	m.Set(1, 10, "password", "budi")
+	if m.ClearStateIf(1, "topup_amount", "enter_voucher") {
+		t.Fatal("cleared a state that was not listed")
+	}
+	if state, _ := m.State(1); state != "flow:create" {
+		t.Fatalf("state = %q, want flow:create kept", state)
+	}
+
+	m.SetState(1, 10, "enter_voucher")
+	if !m.ClearStateIf(1, "topup_amount", "enter_voucher") {
+		t.Fatal("listed state not cleared")
+	}
+	if _, ok := m.State(1); ok {
+		t.Error("state still set")
+	}
+	if got := m.Get(1, "password"); got != "budi" {
+		t.Errorf("data = %q, want it kept", got)
+	}
+	if m.ClearStateIf(2, "enter_voucher") {
+		t.Error("cleared the state of an unknown user")
+	}
+}
+
+func TestForgetLastMessage(t *testing.T) {
+	m := NewManager(0, "")
+	m.SetLastMessage(10, 5)
+	m.ForgetLastMessage(10, 4)
+	if id, ok := m.TakeLastMessage(10); !ok || id != 5 {
+		t.Fatalf("last message = %d, %v, want 5 kept", id, ok)
+	}
+
+	m.SetLastMessage(10, 5)
+	m.ForgetLastMessage(10, 5)
+	if id, ok := m.TakeLastMessage(10); ok {
+		t.Errorf("last message %d not forgotten", id)
+	}
+}
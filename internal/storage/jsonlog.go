@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logMutex serialises appends from the goroutines of one process. Lines are
+// written with a single write call, so appends from other processes do not
+// interleave either.
+var logMutex sync.Mutex
+
+// AppendJSONLine appends v as one JSON line to path, creating it with mode 0600
+func AppendJSONLine(path string, v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	logMutex.Lock()
+	defer logMutex.Unlock()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// ScanJSONLines calls fn with every line of path, oldest first, until fn
+// returns false. A missing file has no lines.
+func ScanJSONLines(path string, fn func(line []byte) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if !fn(scanner.Bytes()) {
+			break
+		}
+	}
+	return scanner.Err()
+}
+
+// AdminAction is one line of the admin audit log shared by both bots
+type AdminAction struct {
+	Time    string `json:"time"`
+	AdminID int64  `json:"admin_id"`
+	Action  string `json:"action"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// LogAdminAction appends an admin action to path
+func LogAdminAction(path string, adminID int64, action, detail string) error {
+	return AppendJSONLine(path, AdminAction{
+		Time:    time.Now().Format("2006-01-02 15:04:05"),
+		AdminID: adminID,
+		Action:  action,
+		Detail:  detail,
+	})
+}
+
+// ReadAdminLog returns the last n admin actions in path, newest first
+func ReadAdminLog(path string, n int) ([]AdminAction, error) {
+	var all []AdminAction
+	err := ScanJSONLines(path, func(line []byte) bool {
+		var a AdminAction
+		if strings.TrimSpace(string(line)) != "" && json.Unmarshal(line, &a) == nil {
+			all = append(all, a)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	var entries []AdminAction
+	for i := len(all) - 1; i >= 0 && len(entries) < n; i-- {
+		entries = append(entries, all[i])
+	}
+	return entries, nil
+}
@@ -0,0 +1,47 @@
+// Package storage reads and writes the JSON files under /etc/zivpn.
+//
+// Writes go to a temporary file that is renamed over the target, so a crash
+// or a full disk never leaves a half-written store behind.
+package storage
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// ReadJSON decodes path into v. A missing file returns an error for which
+// os.IsNotExist is true, so callers can fall back to defaults.
+func ReadJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// LoadJSON is ReadJSON that treats a missing file as empty and leaves v as is
+func LoadJSON(path string, v interface{}) error {
+	if err := ReadJSON(path, v); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// WriteJSON stores v indented in path, atomically
+func WriteJSON(path string, v interface{}, perm os.FileMode) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data, perm)
+}
+
+// WriteFile replaces path with data, atomically
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
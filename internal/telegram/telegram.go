@@ -0,0 +1,82 @@
+// Package telegram holds Telegram helpers shared by the free and paid bots.
+package telegram
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxDownloadSize is the largest file DownloadFile accepts (the Bot API
+// itself serves at most 20 MB)
+const MaxDownloadSize = 20 << 20
+
+var downloadClient = &http.Client{Timeout: time.Minute}
+
+// DownloadFile fetches a file sent to the bot, e.g. a backup to restore
+func DownloadFile(bot *tgbotapi.BotAPI, fileID string) ([]byte, error) {
+	link, err := bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil info file: %w", err)
+	}
+
+	resp, err := downloadClient.Get(link)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengunduh file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gagal mengunduh file: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca file: %w", err)
+	}
+	if len(data) > MaxDownloadSize {
+		return nil, fmt.Errorf("file terlalu besar (maks %d MB)", MaxDownloadSize>>20)
+	}
+	return data, nil
+}
+
+// UserHandle returns @username, or the full name when the user has none
+func UserHandle(u *tgbotapi.User) string {
+	if u == nil {
+		return ""
+	}
+	if u.UserName != "" {
+		return "@" + u.UserName
+	}
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// IsChannelMember reports whether userID has joined channel, given as
+// @username or numeric ID. The bot must be an admin of the channel.
+func IsChannelMember(bot *tgbotapi.BotAPI, channel string, userID int64) (bool, error) {
+	chatConfig := tgbotapi.ChatConfigWithUser{UserID: userID}
+	if id, err := strconv.ParseInt(channel, 10, 64); err == nil {
+		chatConfig.ChatID = id
+	} else {
+		chatConfig.SuperGroupUsername = channel
+	}
+
+	member, err := bot.GetChatMember(tgbotapi.GetChatMemberConfig{ChatConfigWithUser: chatConfig})
+	if err != nil {
+		return false, err
+	}
+	return member.IsCreator() || member.IsAdministrator() || member.Status == "member" || (member.Status == "restricted" && member.IsMember), nil
+}
+
+// DeleteMessage removes a message. Zero IDs are ignored.
+func DeleteMessage(bot *tgbotapi.BotAPI, chatID int64, messageID int) error {
+	if messageID == 0 {
+		return nil
+	}
+	_, err := bot.Request(tgbotapi.NewDeleteMessage(chatID, messageID))
+	return err
+}